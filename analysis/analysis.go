@@ -0,0 +1,120 @@
+// Package analysis implements depscheck dependency analysis.
+//
+// It loads Go packages, walks functions, methods, types, vars
+// and consts they use from imported packages, recursively going
+// into used functions, and returns Results with stats for every
+// selector and imported package. Nothing is printed: rendering
+// results is up to the caller.
+//
+// Typical usage:
+//
+//	results, err := analysis.Analyze(ctx, analysis.Options{Dir: dir}, "./...")
+//	if err != nil {
+//		return err
+//	}
+//	for _, stat := range results.Aggregate.Candidates() {
+//		fmt.Println(stat.Path, stat.LOCCum)
+//	}
+package analysis
+
+import (
+	"context"
+	"fmt"
+)
+
+// Analysis backends.
+const (
+	BackendAST = "ast" // walk AST, following calls and func references
+	BackendSSA = "ssa" // use go/ssa and call graph, see SSA
+)
+
+// Options configures the analysis. Zero value is a valid default:
+// packages of current directory, without tests, stdlib and internal
+// packages, analyzed by AST backend.
+type Options struct {
+	// Dir is a directory to resolve package patterns from,
+	// current directory if empty.
+	Dir string
+
+	// Tests includes tests of analyzed packages.
+	Tests bool
+
+	// Stdlib treats stdlib packages as external dependencies.
+	Stdlib bool
+
+	// Internal includes packages of the same module(s).
+	Internal bool
+
+	// Backend is BackendAST (default) or BackendSSA, with
+	// CallGraph algorithm for the latter (CallGraphVTA by default).
+	Backend   string
+	CallGraph string
+
+	// Ignore lists packages and selectors to be excluded from
+	// results, and Overrides holds per-dependency thresholds.
+	Ignore    IgnoreList
+	Overrides []Override
+
+	// Jobs is a number of packages walked concurrently,
+	// GOMAXPROCS if zero.
+	Jobs int
+
+	// Cache, if set, is used to skip walking dependencies from
+	// module cache, walked by previous runs. It's ignored by SSA
+	// backend.
+	Cache *Cache
+}
+
+// Analyze loads packages matching given patterns and analyzes
+// their dependencies, returning per-package and aggregate results.
+//
+// Loading and walking stop early if ctx is cancelled.
+func Analyze(ctx context.Context, opts Options, patterns ...string) (*Results, error) {
+	_, results, err := walk(ctx, opts, patterns...)
+	return results, err
+}
+
+// walk implements Analyze, returning the walker as well, for
+// commands which need packages and objects behind the results.
+func walk(ctx context.Context, opts Options, patterns ...string) (*Walker, *Results, error) {
+	var algo string
+	switch opts.Backend {
+	case "", BackendAST:
+	case BackendSSA:
+		algo = opts.CallGraph
+		if algo == "" {
+			algo = CallGraphVTA
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown analysis backend: %s", opts.Backend)
+	}
+
+	if algo != "" {
+		opts.Cache = nil
+	}
+
+	pkgs, err := load(ctx, opts, patterns...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	w := NewWalker(pkgs, opts)
+	if algo != "" {
+		if w.SSA, err = NewSSA(w, algo); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	results, err := w.WalkAll(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	// cache is an optimization, so failing to save it is not fatal
+	_ = w.SaveCache()
+	results.Each(func(r *Result) {
+		r.Remove(opts.Ignore)
+		r.Overrides = opts.Overrides
+	})
+
+	return w, results, nil
+}
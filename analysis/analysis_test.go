@@ -0,0 +1,24 @@
+package analysis
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAnalyzeCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Analyze(ctx, Options{Dir: testDir}, "./multi/...")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected analysis to be cancelled, but got %v", err)
+	}
+}
+
+func TestAnalyzeBackend(t *testing.T) {
+	_, err := Analyze(context.Background(), Options{Dir: testDir, Backend: "llvm"}, "./multi/...")
+	if err == nil {
+		t.Fatalf("expected error for unknown backend")
+	}
+}
@@ -0,0 +1,189 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Baseline is a snapshot of Result, used to report only new
+// dependency regressions, ignoring already known ones.
+type Baseline struct {
+	SchemaVersion int `json:"schema_version"`
+
+	Packages  []BaselinePackage  `json:"packages"`
+	Selectors []BaselineSelector `json:"selectors"`
+}
+
+// BaselinePackage holds recorded PackageStat.
+type BaselinePackage struct {
+	Path    string `json:"path"`
+	Module  string `json:"module,omitempty"`
+	Version string `json:"version,omitempty"`
+
+	Count         int `json:"count"`
+	Calls         int `json:"calls"`
+	LOCCum        int `json:"loc_cum"`
+	Depth         int `json:"depth"`
+	DepthInternal int `json:"depth_internal"`
+}
+
+// BaselineSelector holds recorded selector.
+//
+// ID is qualified with package path only, without module version,
+// so updating a dependency doesn't turn its selectors into regressions.
+type BaselineSelector struct {
+	Path string `json:"path"`
+	ID   string `json:"id"`
+}
+
+// NewBaseline creates Baseline from the Result.
+func NewBaseline(r *Result) *Baseline {
+	b := &Baseline{
+		SchemaVersion: SchemaVersion,
+		Packages:      []BaselinePackage{},
+		Selectors:     []BaselineSelector{},
+	}
+
+	for _, stat := range r.PackagesStats() {
+		b.Packages = append(b.Packages, BaselinePackage{
+			Path:          stat.Path,
+			Module:        stat.Module,
+			Version:       stat.Version,
+			Count:         stat.DepsCount,
+			Calls:         stat.DepsCallsCount,
+			LOCCum:        stat.LOCCum,
+			Depth:         stat.Depth,
+			DepthInternal: stat.DepthInternal,
+		})
+	}
+
+	selectors := r.All()
+	sort.Sort(ByID(selectors))
+	for _, sel := range selectors {
+		b.Selectors = append(b.Selectors, BaselineSelector{
+			Path: sel.Pkg.Path,
+			ID:   sel.pathID(),
+		})
+	}
+
+	return b
+}
+
+// LoadBaseline reads Baseline from file.
+func LoadBaseline(filename string) (*Baseline, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var b Baseline
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	if b.SchemaVersion != SchemaVersion {
+		return nil, fmt.Errorf("%s: unsupported baseline version %d, please regenerate it", filename, b.SchemaVersion)
+	}
+	return &b, nil
+}
+
+// Write writes Baseline to file.
+func (b *Baseline) Write(filename string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(data, '\n'), 0644)
+}
+
+// Regressions holds changes in Result, compared to Baseline.
+type Regressions struct {
+	NewPackages  []*PackageStat
+	NewSelectors []*Selector
+	Grown        []Growth
+}
+
+// Growth describes package with grown LOCCum or Depth.
+type Growth struct {
+	Package *PackageStat
+	Old     BaselinePackage
+}
+
+// Compare compares Result with Baseline, returning only new
+// packages and selectors, and packages whose LOCCum or Depth grew.
+func (b *Baseline) Compare(r *Result) *Regressions {
+	packages := make(map[string]BaselinePackage)
+	for _, p := range b.Packages {
+		packages[p.Path] = p
+	}
+	selectors := make(map[BaselineSelector]bool)
+	for _, s := range b.Selectors {
+		selectors[BaselineSelector{Path: s.Path, ID: unversionedID(s, packages[s.Path])}] = true
+	}
+
+	reg := &Regressions{}
+	for _, stat := range r.PackagesStats() {
+		old, ok := packages[stat.Path]
+		if !ok {
+			reg.NewPackages = append(reg.NewPackages, stat)
+			continue
+		}
+		if stat.LOCCum > old.LOCCum || stat.Depth > old.Depth || stat.DepthInternal > old.DepthInternal {
+			reg.Grown = append(reg.Grown, Growth{Package: stat, Old: old})
+		}
+	}
+
+	all := r.All()
+	sort.Sort(ByID(all))
+	for _, sel := range all {
+		if _, ok := packages[sel.Pkg.Path]; !ok {
+			// reported as a new package already
+			continue
+		}
+		if !selectors[BaselineSelector{Path: sel.Pkg.Path, ID: sel.pathID()}] {
+			reg.NewSelectors = append(reg.NewSelectors, sel)
+		}
+	}
+
+	return reg
+}
+
+// unversionedID strips module version from the selector ID, recorded
+// by older baselines with versioned IDs (see Selector.ID).
+func unversionedID(s BaselineSelector, pkg BaselinePackage) string {
+	if pkg.Module == "" || pkg.Version == "" {
+		return s.ID
+	}
+	versioned := pkg.Module + "@" + pkg.Version
+	if !strings.HasPrefix(s.ID, versioned) {
+		return s.ID
+	}
+	return pkg.Module + strings.TrimPrefix(s.ID, versioned)
+}
+
+// Empty returns true if there are no regressions.
+func (reg *Regressions) Empty() bool {
+	return len(reg.NewPackages) == 0 && len(reg.NewSelectors) == 0 && len(reg.Grown) == 0
+}
+
+// Has returns true if package is new, grown or has new selectors.
+func (reg *Regressions) Has(pkg Package) bool {
+	for _, stat := range reg.NewPackages {
+		if stat.Path == pkg.Path {
+			return true
+		}
+	}
+	for _, g := range reg.Grown {
+		if g.Package.Path == pkg.Path {
+			return true
+		}
+	}
+	for _, sel := range reg.NewSelectors {
+		if sel.Pkg.Path == pkg.Path {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBaseline(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "baseline.json")
+	if err := NewBaseline(getResult(t, true, "test/exported.go")).Write(filename); err != nil {
+		t.Fatal(err)
+	}
+	b, err := LoadBaseline(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same code, no regressions
+	src := "test/exported.go"
+	result := getResult(t, true, src)
+	reg := b.Compare(result)
+	if !reg.Empty() {
+		t.Fatalf("%s: expected to have no regressions, but got %+v", src, reg)
+	}
+
+	// Foo.Bar and Foo are used additionally
+	src = "test/exported2.go"
+	result = getResult(t, true, src)
+	reg = b.Compare(result)
+	if len(reg.NewPackages) != 0 {
+		t.Fatalf("%s: expected to have no new packages, but have %d", src, len(reg.NewPackages))
+	}
+	if len(reg.NewSelectors) != 2 {
+		t.Fatalf("%s: expected to have 2 new selectors, but have %d", src, len(reg.NewSelectors))
+	}
+	if len(reg.Grown) != 1 || reg.Grown[0].Old.LOCCum != 15 || reg.Grown[0].Package.LOCCum != 19 {
+		t.Fatalf("%s: expected xsample to grow from 15 to 19 LOC, but got %+v", src, reg.Grown)
+	}
+
+	src = "test/recursion.go"
+	result = getResult(t, true, src)
+	reg = b.Compare(result)
+	if len(reg.NewPackages) != 2 || len(reg.NewSelectors) != 0 {
+		t.Fatalf("%s: expected to have 2 new packages, but got %+v", src, reg)
+	}
+
+	// only regressed packages are suggested
+	result = getResult(t, true, "test/exported.go")
+	result.Regressions = b.Compare(result)
+	if candidates := result.Candidates(); len(candidates) != 0 {
+		t.Fatalf("expected to have no candidates for baseline, but have %d", len(candidates))
+	}
+}
+
+func TestBaselineVersion(t *testing.T) {
+	base := withVersion(getResult(t, true, "test/exported.go"), samplePkg, "v1.0.0")
+	head := withVersion(getResult(t, true, "test/exported.go"), samplePkg, "v1.1.0")
+
+	b := NewBaseline(base)
+	if reg := b.Compare(head); !reg.Empty() {
+		t.Fatalf("expected to have no regressions after version bump, but got %+v", reg)
+	}
+
+	// baselines with versioned selector IDs are still matched
+	for i, s := range b.Selectors {
+		for _, sel := range base.Selectors {
+			if sel.pathID() == s.ID {
+				b.Selectors[i].ID = sel.ID()
+			}
+		}
+	}
+	if reg := b.Compare(head); !reg.Empty() {
+		t.Fatalf("expected to have no regressions for versioned IDs, but got %+v", reg)
+	}
+}
+
+// withVersion returns copy of r with version of the package
+// set for all its selectors and their dependencies.
+func withVersion(r *Result, path, version string) *Result {
+	seen := make(map[*Selector]bool)
+	var set func(sel *Selector)
+	set = func(sel *Selector) {
+		if seen[sel] {
+			return
+		}
+		seen[sel] = true
+		if sel.Pkg.Path == path {
+			sel.Pkg.Version = version
+		}
+		for _, dep := range sel.Deps {
+			set(dep)
+		}
+	}
+
+	ret := NewResult()
+	for key, sel := range r.Selectors {
+		set(sel)
+		ret.Selectors[sel.ID()] = sel
+		ret.Counter[sel.ID()] = r.Counter[key]
+	}
+	return ret
+}
@@ -0,0 +1,449 @@
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// cacheVersion is a version of the cache format, bumped
+// on every incompatible change.
+const cacheVersion = "v6"
+
+// Cache is a persistent cache of walked dependencies.
+//
+// Packages in the module cache never change, so size and uses
+// of every function of such package are saved once, and reused
+// by later runs instead of walking function bodies. Function
+// bodies of cached packages are not even parsed, which saves
+// most of the loading time.
+//
+// Cache is used by AST backend only, as SSA needs function
+// bodies. It's safe for concurrent use.
+type Cache struct {
+	// Dir is a root directory of the cache.
+	Dir string
+
+	once     sync.Once
+	root     string // module cache directory
+	goroot   string // GOROOT/src
+	platform string // GOOS_GOARCH, as cached data depends on build constraints
+
+	mu   sync.Mutex
+	pkgs map[string]*cachedPackage // by key, nil for missing ones
+}
+
+// cachedPackage holds cached data of all funcs and methods of a package,
+// keyed by funcKey.
+type cachedPackage struct {
+	Funcs map[string]*cachedFunc `json:"funcs"`
+}
+
+// cachedFunc holds size of a func or method, and selectors it uses,
+// see Walker.uses.
+type cachedFunc struct {
+	FuncSize
+	Calls []cachedRef `json:"calls,omitempty"`
+}
+
+// cachedRef refers to the selector used by cached func. Leaf
+// selectors (interface methods, etc) are restored as is, others
+// are looked up and walked. Recv of fields is the type declaring
+// them, see Walker.walkField.
+type cachedRef struct {
+	Path string `json:"path"`
+	Name string `json:"name"`
+	Recv string `json:"recv,omitempty"`
+	Type string `json:"type"`
+	Leaf bool   `json:"leaf,omitempty"`
+}
+
+// NewCache creates cache in the given directory.
+func NewCache(dir string) *Cache {
+	return &Cache{
+		Dir:  dir,
+		pkgs: make(map[string]*cachedPackage),
+	}
+}
+
+// DefaultCacheDir returns default cache directory, within
+// user cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "depscheck"), nil
+}
+
+// Clean removes all cached data.
+func (c *Cache) Clean() error {
+	return os.RemoveAll(c.Dir)
+}
+
+// resolve gets module cache directory, GOROOT and target platform
+// from the go command. Cache is disabled if it fails.
+func (c *Cache) resolve() {
+	if c.root != "" {
+		return
+	}
+	out, err := exec.Command("go", "env", "GOMODCACHE", "GOROOT", "GOOS", "GOARCH").Output()
+	if err != nil {
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) != 4 || lines[0] == "" {
+		return
+	}
+	c.root = lines[0]
+	c.goroot = filepath.Join(lines[1], "src")
+	c.platform = lines[2] + "_" + lines[3]
+}
+
+// isStdlib returns true if dir is a stdlib package directory.
+func (c *Cache) isStdlib(dir string) bool {
+	c.once.Do(c.resolve)
+	if c.goroot == "" {
+		return false
+	}
+	rel, err := filepath.Rel(c.goroot, dir)
+	return err == nil && rel != "." && !strings.HasPrefix(rel, "..")
+}
+
+// key returns cache key for the package in the given directory,
+// and false if it's not in module cache.
+func (c *Cache) key(dir string) (string, bool) {
+	c.once.Do(c.resolve)
+	if c.root == "" || dir == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(c.root, dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	return rel, true
+}
+
+// filename returns name of the file, holding package with given key.
+func (c *Cache) filename(key string) string {
+	return filepath.Join(c.Dir, cacheVersion, c.platform, key+".json")
+}
+
+// get returns cached package in the given directory, or nil
+// if it's not cached.
+func (c *Cache) get(dir string) *cachedPackage {
+	key, ok := c.key(dir)
+	if !ok {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if p, ok := c.pkgs[key]; ok {
+		return p
+	}
+
+	var p *cachedPackage
+	if data, err := os.ReadFile(c.filename(key)); err == nil {
+		if err := json.Unmarshal(data, &p); err != nil {
+			p = nil
+		}
+	}
+	c.pkgs[key] = p
+	return p
+}
+
+// put saves package in the given directory to cache.
+func (c *Cache) put(dir string, p *cachedPackage) error {
+	key, ok := c.key(dir)
+	if !ok {
+		return nil
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	// write to temp file first, so concurrent runs never
+	// see partially written data
+	filename := c.filename(key)
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	c.mu.Lock()
+	c.pkgs[key] = p
+	c.mu.Unlock()
+	return nil
+}
+
+// pkgDir returns directory of the package, or empty string
+// if it has no files.
+func pkgDir(pkg *packages.Package) string {
+	if len(pkg.GoFiles) == 0 {
+		return ""
+	}
+	return filepath.Dir(pkg.GoFiles[0])
+}
+
+// funcKey returns key of func or method in cachedPackage.
+func funcKey(recv, name string) string {
+	if recv == "" {
+		return name
+	}
+	return recv + "." + name
+}
+
+// trimmer parses files for go/packages, dropping function bodies
+// of cached packages, and of stdlib ones, unless they're analyzed
+// too. Initial packages are never trimmed, as they are walked from
+// their TypesInfo.
+type trimmer struct {
+	cache   *Cache
+	stdlib  bool            // keep stdlib bodies
+	initial map[string]bool // dirs of initial packages
+
+	mu      sync.Mutex
+	trimmed map[string]bool // dirs of trimmed packages
+}
+
+// parseFile implements packages.Config.ParseFile.
+func (t *trimmer) parseFile(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+	f, err := parser.ParseFile(fset, filename, src, parser.AllErrors|parser.ParseComments)
+	dir := filepath.Dir(filename)
+	if f == nil || t.initial[dir] {
+		return f, err
+	}
+	if (t.stdlib || !t.cache.isStdlib(dir)) && t.cache.get(dir) == nil {
+		return f, err
+	}
+
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		// go/types requires bodies for these
+		if fn.Type.TypeParams != nil || (fn.Recv == nil && fn.Name.Name == "init") {
+			continue
+		}
+		fn.Body = nil
+	}
+
+	t.mu.Lock()
+	t.trimmed[dir] = true
+	t.mu.Unlock()
+	return f, err
+}
+
+// isTrimmed returns true if bodies of package were dropped,
+// so its type errors, like unused imports, should be ignored.
+func (t *trimmer) isTrimmed(pkg *packages.Package) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.trimmed[pkgDir(pkg)]
+}
+
+// cachedFunc returns cached data of the func or method,
+// or nil if there is no cache for it.
+func (w *Walker) cachedFunc(pkg *packages.Package, recv, name string) *cachedFunc {
+	if w.Cache == nil {
+		return nil
+	}
+	p := w.Cache.get(pkgDir(pkg))
+	if p == nil {
+		return nil
+	}
+	return p.Funcs[funcKey(recv, name)]
+}
+
+// walkCached builds Deps from the uses of cached func,
+// the same way WalkFuncBody does.
+func (w *Walker) walkCached(fn *cachedFunc) Deps {
+	var deps Deps
+	for _, ref := range fn.Calls {
+		pkg := w.Package(ref.Path)
+		if pkg == nil {
+			continue
+		}
+
+		var s *Selector
+		if ref.Leaf {
+			if !w.Stdlib && IsStdlib(ref.Path) {
+				continue
+			}
+			s = NewSelector(PackageOf(pkg), ref.Name, ref.Recv, ref.Type, 0)
+		} else {
+			s = w.WalkObject(pkg, lookupObject(pkg, ref.Recv, ref.Name, ref.Type))
+		}
+		if s != nil {
+			deps.Append(s)
+		}
+	}
+	return deps
+}
+
+// lookupObject returns method, field or package-level object of the
+// package with given kind (see ObjectKind), or nil if it's not found.
+// Fields are looked up in the type declaring them.
+func lookupObject(pkg *packages.Package, recv, name, typ string) types.Object {
+	if recv == "" {
+		return pkg.Types.Scope().Lookup(name)
+	}
+
+	tn, ok := pkg.Types.Scope().Lookup(strings.TrimPrefix(recv, "*")).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	if typ == "field" {
+		if f := lookupField(tn.Type().Underlying(), name); f != nil {
+			return f
+		}
+		return nil
+	}
+	named, ok := tn.Type().(*types.Named)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < named.NumMethods(); i++ {
+		if m := named.Method(i); m.Name() == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// lookupField returns field of the struct, including fields of nested
+// anonymous structs, the same way addOwner finds them.
+func lookupField(typ types.Type, name string) *types.Var {
+	st, ok := typ.(*types.Struct)
+	if !ok {
+		return nil
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if f := st.Field(i); f.Name() == name {
+			return f
+		}
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if f := lookupField(st.Field(i).Type(), name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// SaveCache saves walked packages from module cache, which
+// are not cached yet.
+//
+// Every func and method of these packages is saved, as other
+// runs may use them, not only walked ones.
+func (w *Walker) SaveCache() error {
+	if w.Cache == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	var walked []*packages.Package
+	for pkg := range w.indices {
+		walked = append(walked, pkg)
+	}
+	w.mu.Unlock()
+
+	var errs []error
+	for _, pkg := range walked {
+		dir := pkgDir(pkg)
+		if _, ok := w.Cache.key(dir); !ok || w.Cache.get(dir) != nil {
+			continue
+		}
+		if err := w.Cache.put(dir, w.cachePackage(pkg)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// cachePackage builds cached data for all funcs and methods of package.
+func (w *Walker) cachePackage(pkg *packages.Package) *cachedPackage {
+	p := &cachedPackage{Funcs: make(map[string]*cachedFunc)}
+	for _, f := range pkg.Syntax {
+		for _, decl := range f.Decls {
+			fnDecl, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			obj, ok := pkg.TypesInfo.Defs[fnDecl.Name].(*types.Func)
+			if !ok {
+				continue
+			}
+			_, recv, _ := ObjectKind(obj)
+			p.Funcs[funcKey(recv, obj.Name())] = &cachedFunc{
+				FuncSize: w.Size(fnDecl),
+				Calls:    w.cachedCalls(pkg, fnDecl),
+			}
+		}
+	}
+	return p
+}
+
+// cachedCalls returns refs to selectors, used by the function,
+// in the same order WalkFuncBody walks them.
+func (w *Walker) cachedCalls(pkg *packages.Package, node *ast.FuncDecl) []cachedRef {
+	var ret []cachedRef
+	for _, obj := range w.uses(pkg, node) {
+		depPkg := w.Package(obj.Pkg().Path())
+		if depPkg == nil {
+			continue
+		}
+		decl, def := w.FindDefDecl(depPkg, obj)
+		if decl == nil || def == nil {
+			continue
+		}
+		typ, recv, ok := ObjectKind(def)
+		if !ok {
+			continue
+		}
+		if v, ok := def.(*types.Var); ok && v.IsField() {
+			owner := w.index(depPkg).owners[v]
+			if owner == nil {
+				continue
+			}
+			ret = append(ret, cachedRef{Path: depPkg.PkgPath, Name: obj.Name(), Recv: owner.Name(), Type: typ})
+			continue
+		}
+		ret = append(ret, cachedRef{
+			Path: depPkg.PkgPath,
+			Name: obj.Name(),
+			Recv: recv,
+			Type: typ,
+			Leaf: w.FnDecl(depPkg, decl) == nil && w.Spec(depPkg, decl) == nil,
+		})
+	}
+	return ret
+}
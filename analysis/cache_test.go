@@ -0,0 +1,113 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testCache returns cache in dir, treating test directory
+// as a module cache.
+func testCache(t *testing.T, dir string) *Cache {
+	root, err := filepath.Abs(testDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewCache(dir)
+	c.root = root
+	c.platform = "test"
+	return c
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	pattern := "./multi/..."
+	report := func(results *Results) []byte {
+		data, err := json.Marshal(results.JSON(pattern, true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	want := report(analyze(t, Options{Internal: true}, pattern))
+	cold := report(analyze(t, Options{Internal: true, Cache: testCache(t, dir)}, pattern))
+	if !bytes.Equal(cold, want) {
+		t.Fatalf("%s: results with empty cache differ:\n%s\n%s", pattern, cold, want)
+	}
+
+	filename := filepath.Join(dir, cacheVersion, "test", "sample.json")
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("%s: expected sample to be cached: %v", pattern, err)
+	}
+	// initial packages are walked from their TypesInfo, so never used from cache
+	if _, err := os.Stat(filepath.Join(dir, cacheVersion, "test", "multi", "a.json")); err == nil {
+		t.Fatalf("%s: expected initial package not to be cached", pattern)
+	}
+
+	warm := report(analyze(t, Options{Internal: true, Cache: testCache(t, dir)}, pattern))
+	if !bytes.Equal(warm, want) {
+		t.Fatalf("%s: results with warm cache differ:\n%s\n%s", pattern, warm, want)
+	}
+
+	// make sure cached data is used instead of walking
+	var p cachedPackage
+	if err := json.Unmarshal(data, &p); err != nil {
+		t.Fatal(err)
+	}
+	p.Funcs["YFunc"].LOC += 100
+	if data, err = json.Marshal(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := analyze(t, Options{Internal: true, Cache: testCache(t, dir)}, pattern)
+	checkSelector(pattern, t, results.Aggregate, samplePkg+".func.SampleFunc", 2, 6, 114, 0, 2)
+
+	// SSA backend needs function bodies
+	results = analyze(t, Options{Internal: true, Backend: BackendSSA, Cache: testCache(t, dir)}, pattern)
+	checkSelector(pattern, t, results.Aggregate, samplePkg+".func.SampleFunc", 2, 6, 14, 0, 2)
+
+	c := testCache(t, dir)
+	if err := c.Clean(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected cache dir to be removed, but got %v", err)
+	}
+}
+
+func TestLookupObject(t *testing.T) {
+	pkgs, err := Load(context.Background(), testDir, false, "./fields")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg := pkgs[0]
+
+	tests := []struct {
+		recv, name, typ string
+		want            string
+	}{
+		{"", "Status", "func", "func " + fieldsPkg + ".Status() int"},
+		// fields are looked up in their types, not in package scope
+		{"Response", "Status", "field", "field Status int"},
+		{"Options", "Max", "field", "field Max int"},
+		{"Options", "Status", "field", ""},
+	}
+	for _, test := range tests {
+		obj := lookupObject(pkg, test.recv, test.name, test.typ)
+		got := ""
+		if obj != nil {
+			got = types.ObjectString(obj, nil)
+		}
+		if got != test.want {
+			t.Fatalf("expected %s.%s (%s) to be %q, but got %q", test.recv, test.name, test.typ, test.want, got)
+		}
+	}
+}
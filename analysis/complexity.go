@@ -0,0 +1,188 @@
+package analysis
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// FuncCyclomatic calculates cyclomatic complexity of the function
+// declaration: one plus number of branches (if, for, range, case
+// and select clauses, && and ||), or zero if it has no body.
+func FuncCyclomatic(node *ast.FuncDecl) int {
+	if node.Body == nil {
+		return 0
+	}
+
+	complexity := 1
+	ast.Inspect(node.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			complexity++
+		case *ast.CaseClause:
+			if n.List != nil {
+				complexity++
+			}
+		case *ast.CommClause:
+			if n.Comm != nil {
+				complexity++
+			}
+		case *ast.BinaryExpr:
+			if n.Op == token.LAND || n.Op == token.LOR {
+				complexity++
+			}
+		}
+		return true
+	})
+	return complexity
+}
+
+// FuncCognitive calculates cognitive complexity of the function
+// declaration, as defined by G. Ann Campbell in "Cognitive Complexity:
+// A new way of measuring understandability": breaks of linear flow
+// are counted with their nesting level, so nested code is harder
+// to understand than flat one with the same number of branches.
+func FuncCognitive(node *ast.FuncDecl) int {
+	if node.Body == nil {
+		return 0
+	}
+
+	c := &cognitive{
+		decl:    node,
+		elseIfs: make(map[*ast.IfStmt]bool),
+		counted: make(map[*ast.BinaryExpr]bool),
+	}
+	ast.Walk(c, node.Body)
+	return c.complexity
+}
+
+// cognitive is an ast.Visitor, calculating cognitive complexity.
+type cognitive struct {
+	decl       *ast.FuncDecl
+	complexity int
+	nestings   int
+
+	elseIfs map[*ast.IfStmt]bool     // "else if" statements, not nested
+	counted map[*ast.BinaryExpr]bool // parts of already counted sequences
+}
+
+// Visit implements ast.Visitor.
+func (c *cognitive) Visit(n ast.Node) ast.Visitor {
+	switch n := n.(type) {
+	case *ast.IfStmt:
+		if c.elseIfs[n] {
+			c.complexity++
+		} else {
+			c.complexity += 1 + c.nestings
+		}
+		c.walk(n.Init)
+		c.walk(n.Cond)
+		c.nested(n.Body)
+		switch e := n.Else.(type) {
+		case *ast.BlockStmt:
+			c.complexity++
+			c.nested(e)
+		case *ast.IfStmt:
+			c.elseIfs[e] = true
+			c.walk(e)
+		}
+		return nil
+	case *ast.SwitchStmt:
+		c.complexity += 1 + c.nestings
+		c.walk(n.Init)
+		c.walk(n.Tag)
+		c.nested(n.Body)
+		return nil
+	case *ast.TypeSwitchStmt:
+		c.complexity += 1 + c.nestings
+		c.walk(n.Init)
+		c.walk(n.Assign)
+		c.nested(n.Body)
+		return nil
+	case *ast.SelectStmt:
+		c.complexity += 1 + c.nestings
+		c.nested(n.Body)
+		return nil
+	case *ast.ForStmt:
+		c.complexity += 1 + c.nestings
+		c.walk(n.Init)
+		c.walk(n.Cond)
+		c.walk(n.Post)
+		c.nested(n.Body)
+		return nil
+	case *ast.RangeStmt:
+		c.complexity += 1 + c.nestings
+		c.walk(n.Key)
+		c.walk(n.Value)
+		c.walk(n.X)
+		c.nested(n.Body)
+		return nil
+	case *ast.FuncLit:
+		c.nested(n.Body)
+		return nil
+	case *ast.BranchStmt:
+		if n.Label != nil {
+			c.complexity++
+		}
+	case *ast.BinaryExpr:
+		if c.counted[n] || (n.Op != token.LAND && n.Op != token.LOR) {
+			break
+		}
+		// every sequence of the same operators is counted once
+		var last token.Token
+		for _, op := range c.logicalOps(n) {
+			if op != last {
+				c.complexity++
+			}
+			last = op
+		}
+	case *ast.CallExpr:
+		if c.isRecursive(n) {
+			c.complexity++
+		}
+	}
+	return c
+}
+
+// walk walks node, if it's not nil.
+func (c *cognitive) walk(n ast.Node) {
+	if n != nil {
+		ast.Walk(c, n)
+	}
+}
+
+// nested walks node with increased nesting level.
+func (c *cognitive) nested(n ast.Node) {
+	c.nestings++
+	c.walk(n)
+	c.nestings--
+}
+
+// logicalOps returns logical operators of the expression, in order
+// of appearance, marking binary expressions as counted.
+func (c *cognitive) logicalOps(e ast.Expr) []token.Token {
+	switch e := e.(type) {
+	case *ast.ParenExpr:
+		return c.logicalOps(e.X)
+	case *ast.BinaryExpr:
+		if e.Op != token.LAND && e.Op != token.LOR {
+			return nil
+		}
+		c.counted[e] = true
+		ops := c.logicalOps(e.X)
+		ops = append(ops, e.Op)
+		return append(ops, c.logicalOps(e.Y)...)
+	}
+	return nil
+}
+
+// isRecursive returns true if call is a direct call of the function
+// itself. Only names are compared, as type info is not used.
+func (c *cognitive) isRecursive(call *ast.CallExpr) bool {
+	switch fun := call.Fun.(type) {
+	case *ast.Ident:
+		return c.decl.Recv == nil && fun.Name == c.decl.Name.Name
+	case *ast.SelectorExpr:
+		return c.decl.Recv != nil && fun.Sel.Name == c.decl.Name.Name
+	}
+	return false
+}
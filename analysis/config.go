@@ -0,0 +1,272 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFiles is a list of project config file names, looked up
+// in the module root, in order of preference.
+var ConfigFiles = []string{".depscheck.yml", ".depscheck.yaml", ".depscheck.json"}
+
+// Config represents project configuration file.
+//
+// Flag values, if set, are used as defaults for command line
+// flags with the same names (see Flags). FailOn and Thresholds are
+// defaults for -fail-on and -thresholds flags. Policy and Baseline
+// paths are relative to the config file.
+type Config struct {
+	Stdlib    *bool  `yaml:"stdlib" json:"stdlib"`
+	Tests     *bool  `yaml:"tests" json:"tests"`
+	Verbose   *bool  `yaml:"verbose" json:"verbose"`
+	TotalOnly *bool  `yaml:"totalonly" json:"totalonly"`
+	Internal  *bool  `yaml:"internal" json:"internal"`
+	Format    string `yaml:"format" json:"format"`
+	Backend   string `yaml:"backend" json:"backend"`
+	CallGraph string `yaml:"callgraph" json:"callgraph"`
+	Jobs      *int   `yaml:"jobs" json:"jobs"`
+	NoCache   *bool  `yaml:"no_cache" json:"no_cache"`
+	Policy    string `yaml:"policy" json:"policy"`
+	Baseline  string `yaml:"baseline" json:"baseline"`
+
+	FailOn     []string   `yaml:"fail_on" json:"fail_on"`
+	Thresholds Thresholds `yaml:"thresholds" json:"thresholds"`
+
+	Ignore    IgnoreList `yaml:"ignore" json:"ignore"`
+	Overrides []Override `yaml:"overrides" json:"overrides"`
+}
+
+// IgnoreList holds packages and selectors to be excluded
+// from the analysis result.
+//
+// Packages are import paths; path ending with "/..." matches
+// all subpackages as well. Selectors are written as "path.Name"
+// for funcs, types, vars and consts and "path.Recv.Name" for methods.
+type IgnoreList struct {
+	Packages  []string `yaml:"packages" json:"packages"`
+	Selectors []string `yaml:"selectors" json:"selectors"`
+}
+
+// Override changes rules for the single dependency.
+//
+// Path matches package import path or its module path, with
+// optional "/..." suffix for matching all subpackages.
+type Override struct {
+	Path string `yaml:"path" json:"path"`
+
+	// Accept dependency regardless of its size: it's never
+	// suggested for removing and never violates policy.
+	Accept bool `yaml:"accept" json:"accept"`
+
+	// Thresholds replace global ones for this dependency.
+	Thresholds *PartialThresholds `yaml:"thresholds" json:"thresholds"`
+}
+
+// PartialThresholds is a Thresholds with optional values, so
+// values missing in override are taken from global thresholds.
+type PartialThresholds struct {
+	Depth         *int `yaml:"depth" json:"depth"`
+	DepthInternal *int `yaml:"depth_internal" json:"depth_internal"`
+	DepsCount     *int `yaml:"count" json:"count"`
+	LOCCum        *int `yaml:"loc_cum" json:"loc_cum"`
+	Cyclomatic    *int `yaml:"cyclomatic" json:"cyclomatic"`
+	Cognitive     *int `yaml:"cognitive" json:"cognitive"`
+	InitLOCCum    *int `yaml:"init_loc_cum" json:"init_loc_cum"`
+}
+
+// Apply returns t with values replaced by those set in p.
+func (p PartialThresholds) Apply(t Thresholds) Thresholds {
+	set := func(dst *int, v *int) {
+		if v != nil {
+			*dst = *v
+		}
+	}
+	set(&t.Depth, p.Depth)
+	set(&t.DepthInternal, p.DepthInternal)
+	set(&t.DepsCount, p.DepsCount)
+	set(&t.LOCCum, p.LOCCum)
+	set(&t.Cyclomatic, p.Cyclomatic)
+	set(&t.Cognitive, p.Cognitive)
+	set(&t.InitLOCCum, p.InitLOCCum)
+	return t
+}
+
+// NewConfig returns empty Config with default thresholds.
+func NewConfig() *Config {
+	return &Config{
+		Thresholds: DefaultThresholds,
+	}
+}
+
+// FindConfig looks for project config file in the module root
+// of the given dir, returning empty string if not found.
+func FindConfig(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	// find module root by looking for go.mod up the tree
+	root := dir
+	for {
+		if _, err := os.Stat(filepath.Join(root, "go.mod")); err == nil {
+			break
+		}
+		parent := filepath.Dir(root)
+		if parent == root {
+			// not in module, use dir itself
+			root = dir
+			break
+		}
+		root = parent
+	}
+
+	for _, name := range ConfigFiles {
+		filename := filepath.Join(root, name)
+		if _, err := os.Stat(filename); err == nil {
+			return filename, nil
+		}
+	}
+	return "", nil
+}
+
+// LoadConfig reads config from YAML or JSON file, depending
+// on file extension. Unknown keys are reported as errors.
+func LoadConfig(filename string) (*Config, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := NewConfig()
+	if strings.HasSuffix(filename, ".json") {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(cfg)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(cfg)
+	}
+	// empty file is a valid config
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	if err := cfg.Thresholds.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+	for _, s := range cfg.FailOn {
+		if _, err := ParseRule(s); err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	for _, o := range cfg.Overrides {
+		if o.Path == "" {
+			return nil, fmt.Errorf("%s: override without path", filename)
+		}
+	}
+
+	dir := filepath.Dir(filename)
+	for _, path := range []*string{&cfg.Policy, &cfg.Baseline} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+
+	return cfg, nil
+}
+
+// Flags returns values of flags set in config, keyed by flag name.
+func (c *Config) Flags() map[string]string {
+	ret := make(map[string]string)
+	setBool := func(name string, v *bool) {
+		if v != nil {
+			ret[name] = fmt.Sprint(*v)
+		}
+	}
+	setBool("stdlib", c.Stdlib)
+	setBool("tests", c.Tests)
+	setBool("v", c.Verbose)
+	setBool("totalonly", c.TotalOnly)
+	setBool("internal", c.Internal)
+	if c.Format != "" {
+		ret["format"] = c.Format
+	}
+	if c.Backend != "" {
+		ret["backend"] = c.Backend
+	}
+	if c.CallGraph != "" {
+		ret["callgraph"] = c.CallGraph
+	}
+	if c.Jobs != nil {
+		ret["j"] = fmt.Sprint(*c.Jobs)
+	}
+	setBool("no-cache", c.NoCache)
+	if c.Policy != "" {
+		ret["policy"] = c.Policy
+	}
+	if c.Baseline != "" {
+		ret["baseline"] = c.Baseline
+	}
+	return ret
+}
+
+// Matches returns true if override is applicable to the package.
+func (o Override) Matches(pkg Package) bool {
+	return matchPath(o.Path, pkg.Path) || (pkg.Module != "" && matchPath(o.Path, pkg.Module))
+}
+
+// ThresholdsFor returns thresholds for the given package, applying
+// the first matching override. It returns false if package is
+// accepted regardless of its size.
+func ThresholdsFor(t Thresholds, overrides []Override, pkg Package) (Thresholds, bool) {
+	for _, o := range overrides {
+		if !o.Matches(pkg) {
+			continue
+		}
+		if o.Accept {
+			return t, false
+		}
+		if o.Thresholds != nil {
+			return o.Thresholds.Apply(t), true
+		}
+	}
+	return t, true
+}
+
+// Ignores returns true if selector should be excluded from result.
+func (l IgnoreList) Ignores(sel *Selector) bool {
+	for _, p := range l.Packages {
+		if matchPath(p, sel.Pkg.Path) {
+			return true
+		}
+	}
+
+	name := sel.Pkg.Path + "." + sel.Name
+	if sel.Recv != "" {
+		name = sel.Pkg.Path + "." + strings.TrimPrefix(sel.Recv, "*") + "." + sel.Name
+	}
+	for _, s := range l.Selectors {
+		if s == name {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath matches import path against pattern, which is either
+// exact path or path ending with "/..." for path and its subpackages.
+func matchPath(pattern, path string) bool {
+	if prefix := strings.TrimSuffix(pattern, "/..."); prefix != pattern {
+		return path == prefix || strings.HasPrefix(path, prefix+"/")
+	}
+	return pattern == path
+}
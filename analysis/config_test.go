@@ -0,0 +1,143 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfigYAML = `
+stdlib: false
+verbose: true
+format: json
+jobs: 2
+no_cache: true
+baseline: .depscheck-baseline.json
+fail_on: [candidate, loc=500]
+thresholds:
+  loc_cum: 60
+ignore:
+  packages:
+    - github.com/divan/depscheck/test/foo/...
+  selectors:
+    - github.com/divan/depscheck/test/sample.Foo.Bar
+overrides:
+  - path: golang.org/x/sync/...
+    accept: true
+  - path: github.com/divan/depscheck/test/sample
+    thresholds:
+      loc_cum: 10
+`
+
+func TestConfig(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example.com/project\n")
+	writeFile(t, filepath.Join(root, ".depscheck.yml"), testConfigYAML)
+	sub := filepath.Join(root, "cmd", "tool")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	filename, err := FindConfig(sub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filename != filepath.Join(root, ".depscheck.yml") {
+		t.Fatalf("Expecting to find config in module root, but got %q", filename)
+	}
+
+	cfg, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags := cfg.Flags()
+	if flags["v"] != "true" || flags["stdlib"] != "false" || flags["format"] != "json" {
+		t.Fatalf("Unexpected flags from config: %v", flags)
+	}
+	if _, ok := flags["internal"]; ok {
+		t.Fatalf("Expecting unset internal flag to be omitted, but got %v", flags)
+	}
+	if flags["j"] != "2" || flags["no-cache"] != "true" || flags["baseline"] != filepath.Join(root, ".depscheck-baseline.json") {
+		t.Fatalf("Expecting all flags to be mapped, with paths relative to config, but got %v", flags)
+	}
+
+	want := DefaultThresholds
+	want.LOCCum = 60
+	if cfg.Thresholds != want {
+		t.Fatalf("Expecting thresholds to be %v, but got %v", want, cfg.Thresholds)
+	}
+
+	sync := Package{Name: "errgroup", Path: "golang.org/x/sync/errgroup", Module: "golang.org/x/sync"}
+	if _, ok := ThresholdsFor(cfg.Thresholds, cfg.Overrides, sync); ok {
+		t.Fatalf("Expecting %s to be accepted", sync.Path)
+	}
+	sample := Package{Name: "xsample", Path: "github.com/divan/depscheck/test/sample"}
+	th, ok := ThresholdsFor(cfg.Thresholds, cfg.Overrides, sample)
+	want.LOCCum = 10
+	if !ok || th != want {
+		t.Fatalf("Expecting thresholds for %s to be %v, but got %v", sample.Path, want, th)
+	}
+}
+
+func TestConfigJSON(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".depscheck.json")
+	writeFile(t, filename, `{"internal": true, "fail_on": ["foo=1"]}`)
+
+	if _, err := LoadConfig(filename); err == nil {
+		t.Fatalf("Expecting config with invalid rule to fail")
+	}
+
+	writeFile(t, filename, `{"thresholds": {"metric": "slco"}}`)
+	if _, err := LoadConfig(filename); err == nil {
+		t.Fatalf("Expecting config with unknown metric to fail")
+	}
+
+	writeFile(t, filename, `{"internal": true, "write_baseline": "baseline.json"}`)
+	if _, err := LoadConfig(filename); err == nil {
+		t.Fatalf("Expecting config with unknown key to fail")
+	}
+
+	writeFile(t, filename, `{"internal": true, "ignore": {"packages": ["fmt"]}}`)
+	cfg, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Flags()["internal"] != "true" || len(cfg.Ignore.Packages) != 1 {
+		t.Fatalf("Unexpected config: %+v", cfg)
+	}
+}
+
+func TestConfigApply(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".depscheck.yml")
+	writeFile(t, filename, testConfigYAML)
+	cfg, err := LoadConfig(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := "test/exported2.go"
+	result := getResult(t, true, src)
+	result.Remove(cfg.Ignore)
+	checkCount(src, t, result, 2)
+	if _, ok := result.Selectors[samplePkg+".(Foo).method.Bar"]; ok {
+		t.Fatalf("%s: expected ignored method to be removed from result", src)
+	}
+
+	// SampleFunc uses 14 LOC, which is above overridden threshold
+	result.Overrides = cfg.Overrides
+	if candidates := result.Candidates(); len(candidates) != 0 {
+		t.Fatalf("%s: expected to have no candidates, but have %d", src, len(candidates))
+	}
+
+	src = "test/recursion.go"
+	result = getResult(t, true, src)
+	result.Remove(cfg.Ignore)
+	checkCount(src, t, result, 1)
+}
+
+func writeFile(t *testing.T, filename, data string) {
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
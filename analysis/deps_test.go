@@ -0,0 +1,458 @@
+package analysis
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Import paths of fixture packages, used in selector IDs.
+const (
+	samplePkg = "github.com/divan/depscheck/test/sample"
+	fooPkg    = "github.com/divan/depscheck/test/foo"
+	barPkg    = "github.com/divan/depscheck/test/bar"
+	errsPkg   = "github.com/divan/depscheck/test/errs"
+	libPkg    = "github.com/divan/depscheck/test/lib"
+
+	diamondPkg   = "github.com/divan/depscheck/test/diamond"
+	commentedPkg = "github.com/divan/depscheck/test/commented"
+	complexPkg   = "github.com/divan/depscheck/test/complexity"
+	typedPkg     = "github.com/divan/depscheck/test/typed"
+	fieldsPkg    = "github.com/divan/depscheck/test/fields"
+	funcrefPkg   = "github.com/divan/depscheck/test/funcref"
+	initcostPkg  = "github.com/divan/depscheck/test/initcost"
+)
+
+func TestExportedFuncs(t *testing.T) {
+	var result *Result
+	var src string
+
+	src = "test/exported.go"
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 2)
+	checkSelector(src, t, result, samplePkg+".var.Sample", 1, 1, 1, 0, 0)
+	checkSelector(src, t, result, samplePkg+".func.SampleFunc", 1, 6, 14, 0, 2)
+
+	src = "test/exported2.go"
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 3)
+	checkSelector(src, t, result, samplePkg+".func.SampleFunc", 1, 6, 14, 0, 2)
+	checkSelector(src, t, result, samplePkg+".(Foo).method.Bar", 1, 3, 4, 0, 0)
+	checkSelector(src, t, result, samplePkg+".type.Foo", 1, 1, 1, 0, 0)
+
+	src = "test/pkg_renamed.go"
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 3)
+	checkSelector(src, t, result, samplePkg+".func.SampleFunc", 1, 6, 14, 0, 2)
+	checkSelector(src, t, result, samplePkg+".(Foo).method.Bar", 1, 3, 4, 0, 0)
+	checkSelector(src, t, result, samplePkg+".type.Foo", 1, 1, 1, 0, 0)
+
+	src = "test/pkg_dot.go"
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 3)
+	checkSelector(src, t, result, samplePkg+".func.SampleFunc", 1, 6, 14, 0, 2)
+	checkSelector(src, t, result, samplePkg+".(Foo).method.Bar", 1, 3, 4, 0, 0)
+	checkSelector(src, t, result, samplePkg+".type.Foo", 1, 1, 1, 0, 0)
+}
+
+func TestRecursion(t *testing.T) {
+	var result *Result
+	var src string
+
+	src = "test/recursion.go"
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 2)
+	checkSelector(src, t, result, barPkg+".func.Bar", 1, 4, 8, 0, 1)
+	checkSelector(src, t, result, fooPkg+".func.Foo", 1, 4, 12, 1, 0)
+
+	// bar.Bar and bar.Foo call each other
+	cycles := result.Cycles()
+	if len(cycles) != 1 || len(cycles[0]) != 2 || cycles[0][0].ID() != barPkg+".func.Bar" || cycles[0][1].ID() != barPkg+".func.Foo" {
+		t.Fatalf("%s: expected to find bar.Bar, bar.Foo cycle, but got %v", src, cycles)
+	}
+	if result.Selectors[fooPkg+".func.Foo"].IsCycle() {
+		t.Fatalf("%s: expected foo.Foo not to be in cycle", src)
+	}
+}
+
+func TestConsts(t *testing.T) {
+	var result *Result
+	var src string
+
+	src = "test/const.go"
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 1)
+	checkSelector(src, t, result, fooPkg+".const.FooConst", 1, 1, 1, 0, 0)
+}
+
+func TestVars(t *testing.T) {
+	var result *Result
+	var src string
+
+	src = "test/var.go"
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 1)
+	checkSelector(src, t, result, fooPkg+".var.FooVar", 1, 1, 1, 0, 0)
+}
+
+func TestInterface(t *testing.T) {
+	var result *Result
+	var src string
+
+	src = "test/interface.go"
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 2)
+	checkSelector(src, t, result, fooPkg+".(Fooer).method.Foo", 1, 0, 0, 0, 0)
+	checkSelector(src, t, result, fooPkg+".interface.Fooer", 1, 3, 3, 0, 0)
+}
+
+func TestCollisions(t *testing.T) {
+	var result *Result
+	var src string
+
+	src = "test/collision.go"
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 4)
+	checkSelector(src, t, result, errsPkg+"/a.func.New", 1, 2, 5, 0, 0)
+	checkSelector(src, t, result, errsPkg+"/b.func.New", 2, 5, 5, 0, 0)
+	checkSelector(src, t, result, libPkg+".func.Parse", 1, 2, 2, 0, 0)
+	checkSelector(src, t, result, libPkg+"/v2.func.Parse", 1, 6, 6, 0, 0)
+
+	stats := result.PackagesStats()
+	if len(stats) != 4 {
+		t.Fatalf("%s: expected to have stats for 4 packages, but have %d", src, len(stats))
+	}
+	for i, path := range []string{errsPkg + "/a", errsPkg + "/b", libPkg, libPkg + "/v2"} {
+		if stats[i].Path != path {
+			t.Fatalf("%s: expected package #%d to be %s, but got %s", src, i, path, stats[i].Path)
+		}
+	}
+	if stats[1].Name != "errors" || stats[1].DepsCallsCount != 2 {
+		t.Fatalf("%s: expected errors package to have 2 calls, but got %d", src, stats[1].DepsCallsCount)
+	}
+}
+
+func TestLOCUnique(t *testing.T) {
+	src := "test/diamond.go"
+	result := getResult(t, true, src)
+	checkSelector(src, t, result, diamondPkg+".func.Left", 1, 2, 7, 0, 1)
+	checkSelector(src, t, result, diamondPkg+".func.Right", 1, 2, 7, 0, 1)
+	if loc := result.Selectors[diamondPkg+".func.Left"].LOCUnique(); loc != 7 {
+		t.Fatalf("%s: expected Left to have 7 unique LOC, but got %d", src, loc)
+	}
+
+	// helper is shared by Left and Right, but counted once
+	stats := result.PackagesStats()
+	if len(stats) != 1 || stats[0].LOCCum != 14 || stats[0].LOCUnique != 9 {
+		t.Fatalf("%s: expected 14 LOC and 9 unique LOC, but got %v", src, stats)
+	}
+	if totals := result.Totals(src); totals.LOC != 14 || totals.LOCUnique != 9 {
+		t.Fatalf("%s: unexpected totals: %v", src, totals)
+	}
+}
+
+func TestTypeClosure(t *testing.T) {
+	src := "test/typed.go"
+	result := getResult(t, true, src)
+	checkCount(src, t, result, 3)
+	// Config (4 LOC) with Level (1 LOC)
+	checkSelector(src, t, result, typedPkg+".func.Clamp", 1, 5, 10, 0, 0)
+	// Config and Limit
+	checkSelector(src, t, result, typedPkg+".var.Default", 1, 1, 7, 0, 0)
+	checkSelector(src, t, result, typedPkg+".type.List", 1, 4, 9, 0, 0)
+
+	deps := result.Selectors[typedPkg+".type.List"].Deps
+	if len(deps) != 1 || deps[0].ID() != typedPkg+".type.Config" {
+		t.Fatalf("%s: expected List to depend on Config only, but got %v", src, deps)
+	}
+	if cycles := result.Cycles(); len(cycles) != 0 {
+		t.Fatalf("%s: expected to have no cycles, but got %v", src, cycles)
+	}
+
+	stats := result.PackagesStats()
+	if len(stats) != 1 || stats[0].LOCCum != 26 || stats[0].LOCUnique != 16 {
+		t.Fatalf("%s: expected 26 LOC and 16 unique LOC, but got %v", src, stats)
+	}
+}
+
+func TestFields(t *testing.T) {
+	src := "test/fields.go"
+	result := getResult(t, true, src)
+	checkCount(src, t, result, 11)
+
+	// fields are grouped under their types, with types as deps
+	checkSelector(src, t, result, fieldsPkg+".(Options).field.Timeout", 1, 0, 7, 0, 0)
+	checkSelector(src, t, result, fieldsPkg+".(Options).field.Retries", 1, 0, 7, 0, 0)
+	checkSelector(src, t, result, fieldsPkg+".(Options).field.Max", 1, 0, 7, 0, 0)
+	// unkeyed composite literal
+	checkSelector(src, t, result, fieldsPkg+".(Point).field.X", 1, 0, 3, 0, 0)
+	checkSelector(src, t, result, fieldsPkg+".(Point).field.Y", 1, 0, 3, 0, 0)
+	// Response is never named, but its fields are read
+	checkSelector(src, t, result, fieldsPkg+".(Response).field.Body", 2, 0, 4, 0, 0)
+	checkSelector(src, t, result, fieldsPkg+".func.Get", 1, 2, 6, 0, 0)
+
+	// fields are counted as parts of their types: Options and Point
+	// are counted once, and Response is counted in addition to Get
+	stats := result.PackagesStats()
+	if len(stats) != 1 || stats[0].DepsCount != 3 || stats[0].DepsCallsCount != 12 || stats[0].LOCCum != 20 || stats[0].LOCUnique != 16 {
+		t.Fatalf("%s: expected 3 deps, 12 calls, 20 LOC and 16 unique LOC, but got %v", src, stats)
+	}
+}
+
+// Fields, used by dependency code, are its dependencies, like
+// funcs and types it uses.
+func TestFieldsInDeps(t *testing.T) {
+	src := "test/fields_deps.go"
+	for _, cache := range []bool{false, true} {
+		opts := Options{Internal: true}
+		if cache {
+			opts.Cache = testCache(t, t.TempDir())
+			// the first run fills cache
+			analyze(t, opts, relSources(t, []string{src})...)
+		}
+		result := analyze(t, opts, relSources(t, []string{src})...).Aggregate
+		checkCount(src, t, result, 1)
+		// Status reads field of Response, returned by Get
+		checkSelector(src, t, result, fieldsPkg+".func.Status", 1, 2, 12, 0, 1)
+
+		var deps []string
+		for _, dep := range result.Selectors[fieldsPkg+".func.Status"].Deps {
+			deps = append(deps, dep.ID())
+		}
+		want := fieldsPkg + ".func.Get," + fieldsPkg + ".(Response).field.Status"
+		if got := strings.Join(deps, ","); got != want {
+			t.Fatalf("%s (cache %v): expected deps %s, but got %s", src, cache, want, got)
+		}
+	}
+}
+
+func TestFuncRefs(t *testing.T) {
+	src := "test/funcref.go"
+	for _, backend := range []string{BackendAST, BackendSSA} {
+		result := analyze(t, Options{Internal: true, Backend: backend}, relSources(t, []string{src})...).Aggregate
+		checkCount(src, t, result, 5)
+		// called twice and passed as an argument once
+		checkSelector(src, t, result, funcrefPkg+".func.Negate", 3, 2, 2, 0, 0)
+		// method value and method expression, with Greeting
+		checkSelector(src, t, result, funcrefPkg+".func.Greeter", 1, 2, 10, 0, 1)
+		checkSelector(src, t, result, funcrefPkg+".func.Farewell", 1, 3, 11, 0, 1)
+
+		// double is passed to apply, which calls it through
+		// func value, so only SSA sees the call
+		sel := result.Selectors[funcrefPkg+".func.Doubled"]
+		if len(sel.Deps) != 2 || sel.Deps[1].ID() != funcrefPkg+".func.double" {
+			t.Fatalf("%s: expected Doubled to depend on apply and double with %s backend, but got %v", src, backend, sel.Deps)
+		}
+	}
+}
+
+func TestInitCost(t *testing.T) {
+	src := "test/initcost.go"
+	result := getResult(t, true, src)
+	checkCount(src, t, result, 1)
+
+	// var initializers with Driver and compute
+	init := result.Inits[initcostPkg]
+	if init == nil || init.LOC != 2 || init.LOCCum() != 11 {
+		t.Fatalf("%s: expected initcost initialization to have 2 LOC and 11 cumulative LOC, but got %v", src, init)
+	}
+	// init func with Register, registered conn with its methods,
+	// and initialization of initcost itself
+	driver := result.Inits[initcostPkg+"/driver"]
+	if driver == nil || driver.LOC != 2 || driver.LOCCum() != 26 {
+		t.Fatalf("%s: expected driver initialization to have 2 LOC and 26 cumulative LOC, but got %v", src, driver)
+	}
+	if !result.Blank[initcostPkg+"/driver"] || result.Blank[initcostPkg] {
+		t.Fatalf("%s: expected only driver to be imported with blank import, but got %v", src, result.Blank)
+	}
+
+	stats := result.PackagesStats()
+	if len(stats) != 2 {
+		t.Fatalf("%s: expected stats for 2 packages, but got %v", src, stats)
+	}
+	for _, stat := range stats {
+		switch stat.Path {
+		case initcostPkg:
+			if stat.Blank || stat.InitLOCCum != 11 || !stat.CanBeAvoided(DefaultThresholds) {
+				t.Fatalf("%s: expected initcost to be avoidable, with 11 LOC of initialization, but got %+v", src, stat)
+			}
+			th := DefaultThresholds
+			if err := th.Set("init=10"); err != nil {
+				t.Fatal(err)
+			}
+			if stat.CanBeAvoided(th) {
+				t.Fatalf("%s: expected initcost not to be avoidable with init threshold %d: %+v", src, th.InitLOCCum, stat)
+			}
+		case initcostPkg + "/driver":
+			if !stat.Blank || stat.DepsCount != 0 || stat.InitLOCCum != 26 || stat.CanBeAvoided(DefaultThresholds) {
+				t.Fatalf("%s: expected driver to be used for initialization only, with 26 LOC of it, but got %+v", src, stat)
+			}
+		}
+	}
+	if totals := result.Totals(src); totals.InitLOC != 37 {
+		t.Fatalf("%s: expected 37 LOC of initialization in totals, but got %d", src, totals.InitLOC)
+	}
+
+	// packages without initialization are skipped
+	src = "test/fields.go"
+	result = getResult(t, true, src)
+	if len(result.Inits) != 0 {
+		t.Fatalf("%s: expected no initialization, but got %v", src, result.Inits)
+	}
+}
+
+func TestSize(t *testing.T) {
+	src := "test/commented.go"
+	result := getResult(t, true, src)
+	checkSelector(src, t, result, commentedPkg+".func.Compact", 1, 1, 13, 0, 1)
+
+	sel := result.Selectors[commentedPkg+".func.Compact"]
+	doc := sel.Deps[0]
+	if doc.SLOC != 2 || doc.Stmts != 2 {
+		t.Fatalf("%s: expected Documented to have 2 SLOC and 2 statements, but got %d and %d", src, doc.SLOC, doc.Stmts)
+	}
+	if sel.SLOCCum() != 3 || sel.StmtsCum() != 3 {
+		t.Fatalf("%s: expected Compact to have 3 cumulative SLOC and statements, but got %d and %d", src, sel.SLOCCum(), sel.StmtsCum())
+	}
+
+	// package is small only if measured without comments
+	stats := result.PackagesStats()
+	th := DefaultThresholds
+	th.LOCCum = 10
+	if stats[0].CanBeAvoided(th) {
+		t.Fatalf("%s: expected package not to be a candidate by LOC: %v", src, stats[0])
+	}
+	for _, metric := range []string{MetricSLOC, MetricStmts} {
+		th.Metric = metric
+		if !stats[0].CanBeAvoided(th) {
+			t.Fatalf("%s: expected package to be a candidate by %s: %v", src, metric, stats[0])
+		}
+	}
+}
+
+func TestComplexity(t *testing.T) {
+	src := "test/complexity.go"
+	result := getResult(t, true, src)
+
+	tests := []struct {
+		id                    string
+		cyclomatic, cognitive int
+	}{
+		// range, if with ||, if with && and else
+		{complexPkg + ".func.Classify", 6, 9},
+		// if and recursive call
+		{complexPkg + ".func.Fact", 2, 2},
+	}
+	for _, test := range tests {
+		sel := result.Selectors[test.id]
+		if sel == nil {
+			t.Fatalf("%s: expected %s to be used", src, test.id)
+		}
+		if sel.Cyclomatic != test.cyclomatic || sel.Cognitive != test.cognitive {
+			t.Fatalf("%s: expected %s to have complexity %d/%d, but got %d/%d",
+				src, test.id, test.cyclomatic, test.cognitive, sel.Cyclomatic, sel.Cognitive)
+		}
+	}
+
+	stats := result.PackagesStats()
+	want := Complexity{Cyclomatic: 8, CyclomaticMax: 6, Cognitive: 11, CognitiveMax: 9}
+	if stats[0].Complexity != want {
+		t.Fatalf("%s: expected package complexity %+v, but got %+v", src, want, stats[0].Complexity)
+	}
+
+	th := DefaultThresholds
+	th.LOCCum = 100
+	if !stats[0].CanBeAvoided(th) {
+		t.Fatalf("%s: expected package to be a candidate: %v", src, stats[0])
+	}
+	th.Cognitive = 8
+	if stats[0].CanBeAvoided(th) {
+		t.Fatalf("%s: expected package not to be a candidate by cognitive complexity: %v", src, stats[0])
+	}
+}
+
+func TestInternal(t *testing.T) {
+	var result *Result
+	var src string
+
+	src = "test/recursion.go"
+	result = getResult(t, false, src)
+	checkCount(src, t, result, 0)
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 2)
+
+	src = "test/pkg_renamed.go"
+	result = getResult(t, false, src)
+	checkCount(src, t, result, 0)
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 3)
+
+	src = "test/external.go"
+	result = getResult(t, false, src)
+	checkCount(src, t, result, 1)
+	result = getResult(t, true, src)
+	checkCount(src, t, result, 2)
+}
+
+// testDir is a directory with fixtures, which is a separate
+// module (github.com/divan/depscheck/test).
+const testDir = "../test"
+
+// getResult analyzes given sources as a single ad-hoc package.
+//
+// Sources are given as "test/file.go" and resolved within testDir.
+func getResult(t *testing.T, isInternal bool, sources ...string) *Result {
+	return analyze(t, Options{Internal: isInternal}, relSources(t, sources)...).Aggregate
+}
+
+// analyze analyzes given patterns within testDir.
+func analyze(t *testing.T, opts Options, patterns ...string) *Results {
+	opts.Dir = testDir
+	results, err := Analyze(context.Background(), opts, patterns...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return results
+}
+
+// relSources makes sources relative to the "test" directory.
+func relSources(t *testing.T, sources []string) []string {
+	var files []string
+	for _, src := range sources {
+		rel, err := filepath.Rel("test", src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, rel)
+	}
+	return files
+}
+
+func checkCount(src string, t *testing.T, r *Result, want int) {
+	if have := len(r.Counter); have != want {
+		t.Fatalf("%s: expected to have %d selectors, but have %d", src, want, have)
+	}
+}
+
+func checkSelector(src string, t *testing.T, r *Result, fn string, count, loc, loccum, depth, depthint int) {
+	sel, ok := r.Selectors[fn]
+	if !ok {
+		t.Fatalf("%s: expected to see func '%s' in result, but could not", src, fn)
+	}
+	if r.Counter[fn] != count {
+		t.Fatalf("%s: expected to func '%s' to have Count %d , but got %d", src, fn, count, r.Counter[fn])
+	}
+	if sel.LOC != loc {
+		t.Fatalf("%s: expected to func '%s' to have %d LOC, but got %d", src, fn, loc, sel.LOC)
+	}
+	if sel.LOCCum() != loccum {
+		t.Fatalf("%s: expected to func '%s' to have %d Cumulative LOC, but got %d", src, fn, loccum, sel.LOCCum())
+	}
+	if sel.Depth() != depth {
+		t.Fatalf("%s: expected to func '%s' to have Depth %d, but got %d", src, fn, depth, sel.Depth())
+	}
+	if sel.DepthInternal() != depthint {
+		t.Fatalf("%s: expected to func '%s' to have %d Depth Internal, but got %d", src, fn, depthint, sel.DepthInternal())
+	}
+}
@@ -0,0 +1,198 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Diff holds dependency changes between two Results.
+type Diff struct {
+	Base, Head string // analyzed revisions
+
+	Added   []*PackageStat
+	Removed []*PackageStat
+	Changed []PackageDelta
+
+	AddedSelectors   []*Selector
+	RemovedSelectors []*Selector
+}
+
+// PackageDelta holds stats of package, existing in both results.
+type PackageDelta struct {
+	Base, Head *PackageStat
+}
+
+// DiffResults compares base and head results.
+func DiffResults(base, head *Result) *Diff {
+	d := &Diff{}
+
+	baseStats := make(map[string]*PackageStat)
+	for _, stat := range base.PackagesStats() {
+		baseStats[stat.Path] = stat
+	}
+	headStats := make(map[string]*PackageStat)
+	for _, stat := range head.PackagesStats() {
+		headStats[stat.Path] = stat
+
+		old, ok := baseStats[stat.Path]
+		if !ok {
+			d.Added = append(d.Added, stat)
+			continue
+		}
+		if old.LOCCum != stat.LOCCum || old.Depth != stat.Depth || old.DepsCallsCount != stat.DepsCallsCount ||
+			old.Version != stat.Version {
+			d.Changed = append(d.Changed, PackageDelta{Base: old, Head: stat})
+		}
+	}
+	for _, stat := range base.PackagesStats() {
+		if _, ok := headStats[stat.Path]; !ok {
+			d.Removed = append(d.Removed, stat)
+		}
+	}
+
+	d.AddedSelectors = diffSelectors(head, base)
+	d.RemovedSelectors = diffSelectors(base, head)
+
+	return d
+}
+
+// diffSelectors returns selectors of a missing in b, sorted by ID.
+//
+// Selectors are matched by package path, like packages, so version
+// change of the module is reported once, for its packages only.
+func diffSelectors(a, b *Result) []*Selector {
+	known := make(map[string]bool)
+	for _, sel := range b.Selectors {
+		known[sel.pathID()] = true
+	}
+
+	var ret []*Selector
+	for _, sel := range a.Selectors {
+		if !known[sel.pathID()] {
+			ret = append(ret, sel)
+		}
+	}
+	sort.Sort(ByID(ret))
+	return ret
+}
+
+// Empty returns true if there are no changes.
+func (d *Diff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 &&
+		len(d.AddedSelectors) == 0 && len(d.RemovedSelectors) == 0
+}
+
+// WriteMarkdown writes diff in markdown, suitable for posting
+// as a code review comment.
+func (d *Diff) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "### Dependency changes (%s → %s)\n\n", d.Base, d.Head)
+	if d.Empty() {
+		fmt.Fprintln(w, "No dependency changes.")
+		return
+	}
+
+	if len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0 {
+		fmt.Fprintln(w, "| | Package | Module | LOCCum | Depth | Calls |")
+		fmt.Fprintln(w, "|---|---|---|---:|---:|---:|")
+		for _, p := range d.Added {
+			fmt.Fprintf(w, "| + | `%s` | %s | %d | %d | %d |\n", p.Path, p.ModuleString(), p.LOCCum, p.Depth, p.DepsCallsCount)
+		}
+		for _, p := range d.Removed {
+			fmt.Fprintf(w, "| - | `%s` | %s | %d | %d | %d |\n", p.Path, p.ModuleString(), -p.LOCCum, -p.Depth, -p.DepsCallsCount)
+		}
+		for _, c := range d.Changed {
+			fmt.Fprintf(w, "| ~ | `%s` | %s | %s | %s | %s |\n", c.Head.Path, c.moduleString(),
+				delta(c.Base.LOCCum, c.Head.LOCCum), delta(c.Base.Depth, c.Head.Depth), delta(c.Base.DepsCallsCount, c.Head.DepsCallsCount))
+		}
+		fmt.Fprintln(w)
+	}
+
+	writeSelectors := func(title string, selectors []*Selector) {
+		if len(selectors) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "%s:\n\n", title)
+		for _, sel := range selectors {
+			fmt.Fprintf(w, "- `%s`\n", sel.ID())
+		}
+		fmt.Fprintln(w)
+	}
+	writeSelectors("Added selectors", d.AddedSelectors)
+	writeSelectors("Removed selectors", d.RemovedSelectors)
+}
+
+// JSONDiff is a JSON representation of Diff, following
+// the same schema version as JSONReport.
+type JSONDiff struct {
+	SchemaVersion int    `json:"schema_version"`
+	Base          string `json:"base"`
+	Head          string `json:"head"`
+
+	Added   []JSONPackageStat  `json:"added"`
+	Removed []JSONPackageStat  `json:"removed"`
+	Changed []JSONPackageDelta `json:"changed"`
+
+	AddedSelectors   []string `json:"added_selectors"`
+	RemovedSelectors []string `json:"removed_selectors"`
+}
+
+// JSONPackageDelta holds stats of changed package before and after.
+type JSONPackageDelta struct {
+	Base JSONPackageStat `json:"base"`
+	Head JSONPackageStat `json:"head"`
+}
+
+// WriteJSON writes diff in JSON format.
+func (d *Diff) WriteJSON(w io.Writer) error {
+	ret := JSONDiff{
+		SchemaVersion:    SchemaVersion,
+		Base:             d.Base,
+		Head:             d.Head,
+		Added:            []JSONPackageStat{},
+		Removed:          []JSONPackageStat{},
+		Changed:          []JSONPackageDelta{},
+		AddedSelectors:   []string{},
+		RemovedSelectors: []string{},
+	}
+	for _, p := range d.Added {
+		ret.Added = append(ret.Added, jsonPackageStat(p))
+	}
+	for _, p := range d.Removed {
+		ret.Removed = append(ret.Removed, jsonPackageStat(p))
+	}
+	for _, c := range d.Changed {
+		ret.Changed = append(ret.Changed, JSONPackageDelta{
+			Base: jsonPackageStat(c.Base),
+			Head: jsonPackageStat(c.Head),
+		})
+	}
+	for _, sel := range d.AddedSelectors {
+		ret.AddedSelectors = append(ret.AddedSelectors, sel.ID())
+	}
+	for _, sel := range d.RemovedSelectors {
+		ret.RemovedSelectors = append(ret.RemovedSelectors, sel.ID())
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ret)
+}
+
+// moduleString formats module of the package, along with
+// its version change, like "example.com/mod@v1.0.0 → example.com/mod@v1.1.0".
+func (c PackageDelta) moduleString() string {
+	if c.Base.Version == c.Head.Version {
+		return c.Head.ModuleString()
+	}
+	return fmt.Sprintf("%s → %s", c.Base.ModuleString(), c.Head.ModuleString())
+}
+
+// delta formats change of value like "42 (+12)".
+func delta(old, cur int) string {
+	if old == cur {
+		return fmt.Sprintf("%d", cur)
+	}
+	return fmt.Sprintf("%d (%+d)", cur, cur-old)
+}
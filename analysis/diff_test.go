@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	base := getResult(t, true, "test/exported.go")
+	head := getResult(t, true, "test/exported2.go")
+
+	d := DiffResults(base, head)
+	if len(d.Added) != 0 || len(d.Removed) != 0 {
+		t.Fatalf("expected to have no added/removed packages, but got %+v", d)
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Base.LOCCum != 15 || d.Changed[0].Head.LOCCum != 19 {
+		t.Fatalf("expected xsample to change from 15 to 19 LOC, but got %+v", d.Changed)
+	}
+	if len(d.AddedSelectors) != 2 || len(d.RemovedSelectors) != 1 {
+		t.Fatalf("expected 2 added and 1 removed selectors, but got %d and %d", len(d.AddedSelectors), len(d.RemovedSelectors))
+	}
+	if id := d.RemovedSelectors[0].ID(); id != samplePkg+".var.Sample" {
+		t.Fatalf("expected xsample.var.Sample to be removed, but got %s", id)
+	}
+
+	var buf bytes.Buffer
+	d.WriteMarkdown(&buf)
+	if !strings.Contains(buf.String(), "| 19 (+4) |") {
+		t.Fatalf("expected LOC delta in markdown, but got:\n%s", buf.String())
+	}
+
+	d = DiffResults(head, getResult(t, true, "test/recursion.go"))
+	if len(d.Added) != 2 || len(d.Removed) != 1 {
+		t.Fatalf("expected 2 added and 1 removed packages, but got %d and %d", len(d.Added), len(d.Removed))
+	}
+
+	if d = DiffResults(base, base); !d.Empty() {
+		t.Fatalf("expected no changes for the same result, but got %+v", d)
+	}
+}
+
+func TestDiffVersion(t *testing.T) {
+	base := withVersion(getResult(t, true, "test/exported.go"), samplePkg, "v1.0.0")
+	head := withVersion(getResult(t, true, "test/exported.go"), samplePkg, "v1.1.0")
+
+	d := DiffResults(base, head)
+	if len(d.AddedSelectors) != 0 || len(d.RemovedSelectors) != 0 {
+		t.Fatalf("expected no added/removed selectors after version bump, but got %d and %d", len(d.AddedSelectors), len(d.RemovedSelectors))
+	}
+	if len(d.Changed) != 1 || d.Changed[0].Base.Version != "v1.0.0" || d.Changed[0].Head.Version != "v1.1.0" {
+		t.Fatalf("expected version change of xsample, but got %+v", d.Changed)
+	}
+
+	var buf bytes.Buffer
+	d.WriteMarkdown(&buf)
+	if !strings.Contains(buf.String(), "@v1.0.0 → ") {
+		t.Fatalf("expected version change in markdown, but got:\n%s", buf.String())
+	}
+}
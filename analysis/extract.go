@@ -0,0 +1,647 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Extraction is a plan of copying code of the dependency, used by
+// analyzed packages, into a new package of their module, see Extract.
+type Extraction struct {
+	Path    string // import path of the dependency
+	NewPath string // import path of the new package
+	Dir     string // directory of the new package
+
+	// Objects are copied funcs, methods, types, consts
+	// and vars, in order of declaration.
+	Objects []string
+
+	// Files holds content of new and rewritten files
+	// by their names.
+	Files map[string][]byte
+}
+
+// Extract plans copying of the dependency with the given import path
+// into dir, relative to the root of the module of analyzed packages
+// ("internal/<name>" if empty).
+//
+// Everything reachable from the selectors of the dependency, used by
+// analyzed packages, is copied: funcs, types with all their methods,
+// as they may be used through interfaces, consts and vars. License
+// headers of upstream files and license files of its module are kept,
+// and provenance comment is added to every file.
+//
+// Imports of the dependency by analyzed packages and their tests are
+// rewritten to the new package, which keeps the name of dependency, so
+// call sites stay the same. Other packages of the module still import
+// the dependency.
+//
+// Packages and selectors, ignored by opts, are never extracted, unless
+// they're reachable from other selectors.
+//
+// New and rewritten packages are type-checked in memory, and an error
+// is returned if they don't compile. Nothing is written to disk until
+// Write is called.
+func Extract(ctx context.Context, opts Options, path, dir string, patterns ...string) (*Extraction, error) {
+	// bodies are copied, so they must not be trimmed, and tests
+	// are rewritten along with the code
+	opts.Cache = nil
+	opts.Tests = true
+
+	for _, p := range opts.Ignore.Packages {
+		if matchPath(p, path) {
+			return nil, fmt.Errorf("package %s is ignored", path)
+		}
+	}
+
+	w, results, err := walk(ctx, opts, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	dep := w.Package(path)
+	var seeds []types.Object
+	for _, sel := range results.Aggregate.All() {
+		if dep != nil && sel.Pkg.Path == path {
+			if obj := lookupObject(dep, sel.Recv, sel.Name, sel.Type); obj != nil {
+				seeds = append(seeds, obj)
+			}
+		}
+	}
+	if len(seeds) == 0 {
+		return nil, fmt.Errorf("package %s is not used by analyzed packages", path)
+	}
+
+	roots := w.Roots()
+	mod := roots[0].Module
+	if mod == nil || mod.Dir == "" {
+		return nil, errors.New("extract works in module mode only")
+	}
+	if dir == "" {
+		dir = filepath.Join("internal", dep.Name)
+	}
+	dir = filepath.Clean(dir)
+	if filepath.IsAbs(dir) || strings.HasPrefix(dir, "..") {
+		return nil, fmt.Errorf("%s is not within module %s", dir, mod.Path)
+	}
+
+	e := &Extraction{
+		Path:    path,
+		NewPath: mod.Path + "/" + filepath.ToSlash(dir),
+		Dir:     filepath.Join(mod.Dir, dir),
+		Files:   make(map[string][]byte),
+	}
+	if entries, err := os.ReadDir(e.Dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("directory %s already exists", e.Dir)
+	}
+
+	x := newExtractor(w, dep)
+	for _, obj := range seeds {
+		x.add(obj)
+	}
+
+	checked := make(map[string]*types.Package)
+	if checked[e.NewPath], err = e.copyPackage(x, mod.GoVersion); err != nil {
+		return nil, err
+	}
+	if err := checkRoots(roots, e.Files, e.rewriteImports, checked, mod.GoVersion); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Filenames returns names of new and rewritten files, sorted.
+func (e *Extraction) Filenames() []string {
+	return filenames(e.Files)
+}
+
+// Write writes new and rewritten files.
+func (e *Extraction) Write() error {
+	return saveFiles(e.Files)
+}
+
+// copyPackage builds files of the new package from declarations
+// found by extractor, and type-checks them.
+func (e *Extraction) copyPackage(x *extractor, goVersion string) (*types.Package, error) {
+	dep := x.pkg
+	from := dep.PkgPath
+	if dep.Module != nil && dep.Module.Version != "" {
+		from += "@" + dep.Module.Version
+	}
+
+	srcs := make(map[string][]byte)
+	for _, f := range dep.Syntax {
+		filename := dep.Fset.File(f.Pos()).Name()
+		decls, imports, err := x.file(f, filename)
+		if err != nil {
+			return nil, err
+		}
+		if len(decls) == 0 {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if header := x.header(f); header != "" {
+			fmt.Fprintf(&buf, "%s\n\n", header)
+		}
+		fmt.Fprintf(&buf, "// Copied from %s (%s) by depscheck extract.\n\n", from, filepath.Base(filename))
+		fmt.Fprintf(&buf, "package %s\n\n", dep.Name)
+		if len(imports) > 0 {
+			fmt.Fprintf(&buf, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+		}
+		buf.WriteString(strings.Join(decls, "\n\n"))
+
+		src, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		srcs[filepath.Join(e.Dir, filepath.Base(filename))] = src
+	}
+
+	for spec := range x.imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); !canImport(e.NewPath, p) {
+			return nil, fmt.Errorf("copied code of %s imports %s, which is internal to another module", e.Path, p)
+		}
+	}
+
+	pkg, err := typeCheck(e.NewPath, dep.Name, srcs, dep.Imports, nil, goVersion)
+	if err != nil {
+		return nil, fmt.Errorf("copied code of %s doesn't compile: %w", e.Path, err)
+	}
+
+	for name, src := range srcs {
+		e.Files[name] = src
+	}
+	sort.Slice(x.objects, func(i, j int) bool {
+		return x.objects[i].Pos() < x.objects[j].Pos()
+	})
+	for _, obj := range x.objects {
+		name := obj.Name()
+		if _, recv, _ := ObjectKind(obj); recv != "" {
+			name = recv + "." + name
+		}
+		e.Objects = append(e.Objects, name)
+	}
+
+	// license files are usually kept in the module root
+	if dep.Module != nil && dep.Module.Dir != "" {
+		entries, _ := os.ReadDir(dep.Module.Dir)
+		for _, entry := range entries {
+			if entry.IsDir() || !isLicense(entry.Name()) {
+				continue
+			}
+			data, err := os.ReadFile(filepath.Join(dep.Module.Dir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			e.Files[filepath.Join(e.Dir, entry.Name())] = data
+		}
+	}
+
+	return pkg, nil
+}
+
+// checkRoots type-checks root packages after edit changes their
+// syntax, returning true for changed files. Changed files are saved
+// to files, which may also hold new files of root packages.
+//
+// Packages are checked after ones they import, so they see types
+// of changed packages, and are saved to checked.
+func checkRoots(roots []*packages.Package, files map[string][]byte, edit func(*ast.File, *packages.Package) bool, checked map[string]*types.Package, goVersion string) error {
+	byPath := make(map[string]*packages.Package)
+	for _, pkg := range roots {
+		byPath[pkg.PkgPath] = pkg
+	}
+
+	var check func(pkg *packages.Package) error
+	check = func(pkg *packages.Package) error {
+		if _, ok := checked[pkg.PkgPath]; ok {
+			return nil
+		}
+		for _, imp := range pkg.Imports {
+			if root, ok := byPath[imp.PkgPath]; ok && root != pkg {
+				if err := check(root); err != nil {
+					return err
+				}
+			}
+		}
+
+		srcs := make(map[string][]byte)
+		for _, f := range pkg.Syntax {
+			filename := pkg.Fset.File(f.Pos()).Name()
+			src, ok := files[filename]
+			if !ok && edit(f, pkg) {
+				var buf bytes.Buffer
+				if err := format.Node(&buf, pkg.Fset, f); err != nil {
+					return fmt.Errorf("%s: %v", filename, err)
+				}
+				src, ok = buf.Bytes(), true
+				files[filename] = src
+			}
+			if !ok {
+				var err error
+				if src, err = os.ReadFile(filename); err != nil {
+					return err
+				}
+			}
+			srcs[filename] = src
+		}
+		// new files never belong to external tests
+		if !strings.HasSuffix(pkg.Name, "_test") {
+			for filename, src := range files {
+				if _, ok := srcs[filename]; !ok && strings.HasSuffix(filename, ".go") && filepath.Dir(filename) == pkgDir(pkg) {
+					srcs[filename] = src
+				}
+			}
+		}
+
+		typesPkg, err := typeCheck(pkg.PkgPath, pkg.Name, srcs, pkg.Imports, checked, goVersion)
+		if err != nil {
+			return fmt.Errorf("%s doesn't compile after changes: %w", pkg.PkgPath, err)
+		}
+		checked[pkg.PkgPath] = typesPkg
+		return nil
+	}
+
+	for _, pkg := range roots {
+		if err := check(pkg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewriteImports replaces imports of the dependency in file with
+// the new package, returning true if file was changed.
+func (e *Extraction) rewriteImports(f *ast.File, pkg *packages.Package) bool {
+	var changed bool
+	for _, spec := range f.Imports {
+		p, err := strconv.Unquote(spec.Path.Value)
+		if err != nil || pkg.Imports[p] == nil || pkg.Imports[p].PkgPath != e.Path {
+			continue
+		}
+		name := pkg.Imports[p].Name
+		spec.Path.Value = strconv.Quote(e.NewPath)
+		if spec.Name == nil && path.Base(e.NewPath) != name {
+			spec.Name = ast.NewIdent(name)
+		}
+		changed = true
+	}
+	if changed {
+		ast.SortImports(pkg.Fset, f)
+	}
+	return changed
+}
+
+// extractor finds declarations of the dependency, needed to copy
+// given objects.
+type extractor struct {
+	w   *Walker
+	pkg *packages.Package
+
+	genDecls map[ast.Spec]*ast.GenDecl
+	seen     map[types.Object]bool
+	objects  []types.Object
+
+	funcs   map[*ast.FuncDecl]bool
+	whole   map[*ast.GenDecl]bool // decls copied with all their specs
+	specs   map[ast.Spec]bool
+	imports map[*ast.ImportSpec]bool
+}
+
+func newExtractor(w *Walker, pkg *packages.Package) *extractor {
+	x := &extractor{
+		w:        w,
+		pkg:      pkg,
+		genDecls: make(map[ast.Spec]*ast.GenDecl),
+		seen:     make(map[types.Object]bool),
+		funcs:    make(map[*ast.FuncDecl]bool),
+		whole:    make(map[*ast.GenDecl]bool),
+		specs:    make(map[ast.Spec]bool),
+		imports:  make(map[*ast.ImportSpec]bool),
+	}
+	for _, f := range pkg.Syntax {
+		for _, d := range f.Decls {
+			if d, ok := d.(*ast.GenDecl); ok {
+				for _, spec := range d.Specs {
+					x.genDecls[spec] = d
+				}
+			}
+		}
+	}
+	return x
+}
+
+// add marks declaration of the object, and everything it refers to,
+// to be copied. Objects of other packages, fields and interface
+// methods have nothing to copy.
+func (x *extractor) add(obj types.Object) {
+	if fn, ok := obj.(*types.Func); ok {
+		obj = fn.Origin()
+	}
+	// fields are copied with their structs
+	if v, ok := obj.(*types.Var); ok && v.IsField() {
+		owner := x.w.index(x.pkg).owners[v.Origin()]
+		if owner == nil {
+			return
+		}
+		obj = owner
+	}
+	if obj == nil || obj.Pkg() != x.pkg.Types || x.seen[obj] {
+		return
+	}
+	x.seen[obj] = true
+
+	ident, _ := x.w.FindDefDecl(x.pkg, obj)
+	if ident == nil {
+		return
+	}
+	var node ast.Node
+	if fn := x.w.FnDecl(x.pkg, ident); fn != nil {
+		x.funcs[fn] = true
+		node = fn
+	} else if spec := x.w.Spec(x.pkg, ident); spec != nil {
+		// consts of a group may depend on each other, via iota
+		// or implicit repetition, so they're never split
+		decl := x.genDecls[spec]
+		if decl.Tok == token.CONST && decl.Lparen.IsValid() {
+			x.whole[decl] = true
+			node = decl
+		} else {
+			x.specs[spec] = true
+			node = spec
+		}
+	} else {
+		return
+	}
+
+	x.objects = append(x.objects, obj)
+
+	if tn, ok := obj.(*types.TypeName); ok {
+		if named, ok := tn.Type().(*types.Named); ok {
+			for i := 0; i < named.NumMethods(); i++ {
+				x.add(named.Method(i))
+			}
+		}
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok {
+			if obj := x.pkg.TypesInfo.Uses[ident]; obj != nil {
+				x.add(obj)
+			}
+		}
+		return true
+	})
+}
+
+// file returns source of the copied declarations of file, in order
+// of appearance, and import specs they need.
+func (x *extractor) file(f *ast.File, filename string) (decls, imports []string, err error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, err
+	}
+	text := func(from, to token.Pos) string {
+		return string(src[x.pkg.Fset.Position(from).Offset:x.pkg.Fset.Position(to).Offset])
+	}
+
+	var nodes []ast.Node
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if x.funcs[d] {
+				decls = append(decls, text(docPos(d.Doc, d.Pos()), d.End()))
+				nodes = append(nodes, d)
+			}
+		case *ast.GenDecl:
+			var specs []string
+			for _, spec := range d.Specs {
+				if x.whole[d] || x.specs[spec] {
+					specs = append(specs, text(specRange(spec)))
+					nodes = append(nodes, spec)
+				}
+			}
+			switch {
+			case len(specs) == 0:
+			case !d.Lparen.IsValid() || x.whole[d]:
+				decls = append(decls, text(docPos(d.Doc, d.Pos()), d.End()))
+			default:
+				decls = append(decls, fmt.Sprintf("%s (\n%s\n)", d.Tok, strings.Join(specs, "\n")))
+			}
+		}
+	}
+
+	// only imports referred to by copied code are kept
+	used := make(map[string]bool)
+	dots := make(map[string]bool)
+	for _, spec := range f.Imports {
+		if spec.Name != nil && spec.Name.Name == "." {
+			p, _ := strconv.Unquote(spec.Path.Value)
+			dots[p] = true
+		}
+	}
+	for _, node := range nodes {
+		ast.Inspect(node, func(n ast.Node) bool {
+			ident, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			switch obj := x.pkg.TypesInfo.Uses[ident].(type) {
+			case nil:
+			case *types.PkgName:
+				used[obj.Imported().Path()] = true
+			default:
+				if obj.Pkg() != nil && dots[obj.Pkg().Path()] {
+					used[obj.Pkg().Path()] = true
+				}
+			}
+			return true
+		})
+	}
+	for _, spec := range f.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		if imp := x.pkg.Imports[p]; imp != nil && used[imp.PkgPath] {
+			imports = append(imports, text(spec.Pos(), spec.End()))
+			x.imports[spec] = true
+		}
+	}
+
+	return decls, imports, nil
+}
+
+// header returns comments of file before its package clause, which
+// usually hold license and build constraints. Package doc is skipped,
+// unless it's a license too, not separated from the package clause.
+func (x *extractor) header(f *ast.File) string {
+	var ret []string
+	for _, c := range f.Comments {
+		if c.Pos() >= f.Package {
+			break
+		}
+		if c == f.Doc && strings.HasPrefix(c.Text(), "Package ") {
+			continue
+		}
+		var lines []string
+		for _, comment := range c.List {
+			lines = append(lines, comment.Text)
+		}
+		ret = append(ret, strings.Join(lines, "\n"))
+	}
+	return strings.Join(ret, "\n\n")
+}
+
+// docPos returns position of the doc comment, if any, or pos.
+func docPos(doc *ast.CommentGroup, pos token.Pos) token.Pos {
+	if doc != nil {
+		return doc.Pos()
+	}
+	return pos
+}
+
+// specRange returns range of the spec, with its doc
+// and line comments.
+func specRange(spec ast.Spec) (token.Pos, token.Pos) {
+	var doc, comment *ast.CommentGroup
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		doc, comment = spec.Doc, spec.Comment
+	case *ast.ValueSpec:
+		doc, comment = spec.Doc, spec.Comment
+	}
+	end := spec.End()
+	if comment != nil {
+		end = comment.End()
+	}
+	return docPos(doc, spec.Pos()), end
+}
+
+// canImport returns true if package with the given path
+// is allowed to import package p, with regard to internal
+// packages.
+func canImport(path, p string) bool {
+	i := strings.LastIndex(p, "/internal/")
+	switch {
+	case i >= 0:
+	case strings.HasSuffix(p, "/internal"):
+		i = len(p) - len("/internal")
+	case strings.HasPrefix(p, "internal/") || p == "internal":
+		// stdlib internals
+		return false
+	default:
+		return true
+	}
+	return strings.HasPrefix(path, p[:i]+"/")
+}
+
+// filenames returns names of files, sorted.
+func filenames(files map[string][]byte) []string {
+	var ret []string
+	for name := range files {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// saveFiles writes files, creating their directories if needed.
+func saveFiles(files map[string][]byte) error {
+	for _, name := range filenames(files) {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, files[name], 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isLicense returns true if file name looks like a license file.
+func isLicense(name string) bool {
+	name = strings.ToUpper(name)
+	for _, prefix := range []string{"LICENSE", "LICENCE", "COPYING", "NOTICE"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeCheck type-checks package with the given path from sources,
+// resolving imports with loaded packages, or packages from checked,
+// if they were rewritten.
+func typeCheck(path, name string, srcs map[string][]byte, imports map[string]*packages.Package, checked map[string]*types.Package, goVersion string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	var names []string
+	for filename := range srcs {
+		names = append(names, filename)
+	}
+	sort.Strings(names)
+
+	var files []*ast.File
+	for _, filename := range names {
+		f, err := parser.ParseFile(fset, filename, srcs[filename], 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	// report only first errors, others are likely caused by them
+	const maxErrors = 10
+	var errs []error
+	conf := &types.Config{
+		Importer: importerFunc(func(p string) (*types.Package, error) {
+			imp := imports[p]
+			if imp != nil {
+				p = imp.PkgPath
+			}
+			if pkg, ok := checked[p]; ok {
+				return pkg, nil
+			}
+			if imp != nil && imp.Types != nil {
+				return imp.Types, nil
+			}
+			if p == "unsafe" {
+				return types.Unsafe, nil
+			}
+			return nil, fmt.Errorf("package %s is not loaded", p)
+		}),
+		Error: func(err error) {
+			if len(errs) < maxErrors {
+				errs = append(errs, err)
+			}
+		},
+	}
+	if goVersion != "" {
+		conf.GoVersion = "go" + goVersion
+	}
+	pkg, _ := conf.Check(path, fset, files, nil)
+	if pkg != nil && pkg.Name() != name {
+		errs = append(errs, fmt.Errorf("expected package %s, but got %s", name, pkg.Name()))
+	}
+	return pkg, errors.Join(errs...)
+}
+
+// importerFunc implements types.Importer.
+type importerFunc func(path string) (*types.Package, error)
+
+// Import implements types.Importer.
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
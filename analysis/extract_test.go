@@ -0,0 +1,162 @@
+package analysis
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":  "module example.com/ext\n\ngo 1.25\n",
+		"LICENSE": "Copyright (c) The Pad Authors\n",
+		"pad/pad.go": `// Copyright (c) The Pad Authors.
+// Use of this source code is governed by a MIT license.
+
+// Package pad pads strings.
+package pad
+
+import (
+	"errors"
+	"strings"
+)
+
+// Width is a default width.
+const Width = 8
+
+const (
+	Left Side = iota
+	Right
+)
+
+type Side int
+
+// Pad pads s to width.
+func Pad(s string, side Side) string {
+	if side == Left {
+		return fill(len(s)) + s
+	}
+	return s + fill(len(s))
+}
+
+func fill(n int) string {
+	return strings.Repeat(" ", Width-n)
+}
+
+// Error is returned by nothing, but is used by nothing too.
+var Error = errors.New("pad")
+
+type tooLong struct{ n int }
+
+func (e *tooLong) Error() string { return "too long" }
+
+func Check(s string) error {
+	if len(s) > Width {
+		return &tooLong{len(s)}
+	}
+	return nil
+}
+`,
+		"app/main.go": `package main
+
+import (
+	"fmt"
+
+	"example.com/ext/pad"
+)
+
+func main() {
+	fmt.Println(pad.Pad("x", pad.Right))
+}
+`,
+		"app/main_test.go": `package main
+
+import (
+	"testing"
+
+	"example.com/ext/pad"
+)
+
+func TestCheck(t *testing.T) {
+	if err := pad.Check("x"); err != nil {
+		t.Fatal(err)
+	}
+}
+`,
+	})
+
+	ctx := context.Background()
+	e, err := Extract(ctx, Options{Dir: dir, Internal: true}, "example.com/ext/pad", "", "./app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.NewPath != "example.com/ext/internal/pad" {
+		t.Fatalf("expected new package to be example.com/ext/internal/pad, but got %s", e.NewPath)
+	}
+
+	ignore := IgnoreList{Packages: []string{"example.com/ext/pad"}}
+	if _, err := Extract(ctx, Options{Dir: dir, Internal: true, Ignore: ignore}, "example.com/ext/pad", "", "./app"); err == nil {
+		t.Fatal("expected extraction to fail for ignored package")
+	}
+
+	// tooLong is copied with its method, as it's used as error
+	want := "Width,Left,Right,Side,Pad,fill,tooLong,*tooLong.Error,Check"
+	if got := strings.Join(e.Objects, ","); got != want {
+		t.Fatalf("expected to copy %s, but got %s", want, got)
+	}
+
+	files := e.Filenames()
+	var names []string
+	for _, filename := range files {
+		rel, err := filepath.Rel(dir, filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, filepath.ToSlash(rel))
+	}
+	if got := strings.Join(names, ","); got != "app/main.go,app/main_test.go,internal/pad/LICENSE,internal/pad/pad.go" {
+		t.Fatalf("unexpected files: %s", got)
+	}
+
+	copied := string(e.Files[filepath.Join(dir, "internal", "pad", "pad.go")])
+	for _, s := range []string{
+		"// Copyright (c) The Pad Authors.",
+		"// Copied from example.com/ext/pad (pad.go) by depscheck extract.",
+		"\n\t\"strings\"\n",
+		"// Pad pads s to width.",
+	} {
+		if !strings.Contains(copied, s) {
+			t.Fatalf("expected copied code to contain %q, but got:\n%s", s, copied)
+		}
+	}
+	for _, s := range []string{"Package pad", "errors", "var Error"} {
+		if strings.Contains(copied, s) {
+			t.Fatalf("expected copied code not to contain %q, but got:\n%s", s, copied)
+		}
+	}
+
+	if err := e.Write(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "app", "main.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"example.com/ext/internal/pad"`) {
+		t.Fatalf("expected import to be rewritten, but got:\n%s", data)
+	}
+	if _, err := Load(ctx, dir, true, "./..."); err != nil {
+		t.Fatalf("expected module to compile after extraction: %v", err)
+	}
+
+	// nothing is overwritten
+	if _, err := Extract(ctx, Options{Dir: dir, Internal: true}, "example.com/ext/pad", "internal/pad", "./app"); err == nil {
+		t.Fatal("expected extraction to fail for package, which is not used anymore")
+	}
+	if _, err := Extract(ctx, Options{Dir: dir, Internal: true}, "example.com/ext/internal/pad", "internal/pad", "./app"); err == nil {
+		t.Fatal("expected extraction to fail for existing directory")
+	}
+}
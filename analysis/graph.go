@@ -0,0 +1,194 @@
+package analysis
+
+import "sort"
+
+// StronglyConnected returns strongly connected components of the
+// graph, reachable from roots, using Tarjan's algorithm. Edges
+// are given by succ.
+//
+// Components are returned in dependency order: every component
+// comes after all components reachable from it, so metrics could
+// be computed in a single pass over the condensation.
+func StronglyConnected[T comparable](roots []T, succ func(T) []T) [][]T {
+	var (
+		index   = make(map[T]int)
+		lowlink = make(map[T]int)
+		onStack = make(map[T]bool)
+		stack   []T
+		ret     [][]T
+	)
+
+	var connect func(v T)
+	connect = func(v T) {
+		index[v] = len(index)
+		lowlink[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, w := range succ(v) {
+			if _, ok := index[w]; !ok {
+				connect(w)
+				lowlink[v] = min(lowlink[v], lowlink[w])
+			} else if onStack[w] {
+				lowlink[v] = min(lowlink[v], index[w])
+			}
+		}
+
+		if lowlink[v] != index[v] {
+			return
+		}
+		var comp []T
+		for {
+			w := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[w] = false
+			comp = append(comp, w)
+			if w == v {
+				break
+			}
+		}
+		ret = append(ret, comp)
+	}
+
+	for _, v := range roots {
+		if _, ok := index[v]; !ok {
+			connect(v)
+		}
+	}
+	return ret
+}
+
+// component is a strongly connected component of selectors graph,
+// holding metrics shared by all its selectors.
+//
+// Selectors in a cycle reach each other, so they're all treated
+// as a single node: its LOC is a sum of their LOC, and deps are
+// all deps of its selectors, outside of the component.
+type component struct {
+	selectors []*Selector
+
+	locCum        int
+	slocCum       int
+	stmtsCum      int
+	depth         int
+	depthInternal int
+}
+
+// component returns component of the selector, building
+// components for all selectors reachable from it, if needed.
+func (s *Selector) component() *component {
+	if s.comp == nil {
+		buildComponents(s)
+	}
+	return s.comp
+}
+
+// buildComponents finds components for selectors, reachable from s,
+// and computes their metrics. Selectors with components already
+// found are never revisited: nothing unvisited is reachable from them.
+func buildComponents(s *Selector) {
+	succ := func(s *Selector) []*Selector {
+		var ret []*Selector
+		for _, dep := range s.Deps {
+			if dep.comp == nil {
+				ret = append(ret, dep)
+			}
+		}
+		return ret
+	}
+
+	for _, selectors := range StronglyConnected([]*Selector{s}, succ) {
+		c := &component{selectors: selectors}
+		in := make(map[*Selector]bool)
+		for _, sel := range selectors {
+			sel.comp = c
+			in[sel] = true
+		}
+
+		// cycle is a chain of nesting through all its selectors;
+		// calls into another package are levels of external nesting,
+		// one per package. Cycles within a package are the only ones
+		// AST walker sees, but with SSA backend calls through
+		// interfaces and func values make cross-package cycles too.
+		pkgs := make(map[Package]bool)
+		for _, sel := range selectors {
+			pkgs[sel.Pkg] = true
+		}
+		c.depth = len(pkgs) - 1
+		c.depthInternal = len(selectors) - len(pkgs)
+		for _, sel := range selectors {
+			c.locCum += sel.LOC
+			c.slocCum += sel.SLOC
+			c.stmtsCum += sel.Stmts
+			for _, dep := range withoutFields(sel.Deps) {
+				if in[dep] {
+					continue
+				}
+				c.locCum += dep.LOCCum()
+				c.slocCum += dep.SLOCCum()
+				c.stmtsCum += dep.StmtsCum()
+				// types, consts and vars don't nest calls
+				if !dep.IsFunc() {
+					continue
+				}
+				if dep.Pkg != sel.Pkg {
+					c.depth += 1 + dep.Depth()
+				} else {
+					c.depthInternal += 1 + dep.DepthInternal()
+				}
+			}
+		}
+	}
+}
+
+// withoutFields returns deps with fields replaced by their types, which
+// are not deps yet. Fields are parts of their types, so every type is
+// counted once, however many of its fields are used.
+func withoutFields(deps Deps) Deps {
+	var ret Deps
+	seen := make(map[*Selector]bool)
+	for _, dep := range deps {
+		if dep.Type != "field" {
+			seen[dep] = true
+		}
+	}
+	for _, dep := range deps {
+		if dep.Type != "field" {
+			ret = append(ret, dep)
+			continue
+		}
+		for _, owner := range dep.Deps {
+			if !seen[owner] {
+				seen[owner] = true
+				ret = append(ret, owner)
+			}
+		}
+	}
+	return ret
+}
+
+// IsCycle returns true if selector calls itself, directly
+// or through other selectors.
+func (s *Selector) IsCycle() bool {
+	c := s.component()
+	if len(c.selectors) > 1 {
+		return true
+	}
+	for _, dep := range s.Deps {
+		if dep == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Cycle returns all selectors of the cycle, selector belongs to,
+// sorted by ID, or nil if it's not in a cycle.
+func (s *Selector) Cycle() []*Selector {
+	if !s.IsCycle() {
+		return nil
+	}
+	ret := append([]*Selector(nil), s.component().selectors...)
+	sort.Sort(ByID(ret))
+	return ret
+}
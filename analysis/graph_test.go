@@ -0,0 +1,47 @@
+package analysis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStronglyConnected(t *testing.T) {
+	// 1 -> 2 -> 3 -> 1, 3 -> 4, 4 -> 4, 5 -> 4
+	edges := map[int][]int{
+		1: {2},
+		2: {3},
+		3: {1, 4},
+		4: {4},
+		5: {4},
+	}
+	succ := func(v int) []int { return edges[v] }
+
+	comps := StronglyConnected([]int{1, 5}, succ)
+	if have, want := fmt.Sprint(comps), "[[4] [3 2 1] [5]]"; have != want {
+		t.Fatalf("expected components %s, but got %s", want, have)
+	}
+}
+
+func TestComponents(t *testing.T) {
+	pkg := Package{Name: "lib", Path: "lib"}
+	a := NewSelector(pkg, "A", "", "func", 2)
+	b := NewSelector(pkg, "B", "", "func", 3)
+	c := NewSelector(pkg, "C", "", "func", 5)
+	ext := NewSelector(Package{Name: "ext", Path: "ext"}, "Ext", "", "func", 7)
+	a.Deps = Deps{b}
+	b.Deps = Deps{a, c, ext}
+	c.Deps = Deps{c}
+
+	if a.LOCCum() != 17 || b.LOCCum() != 17 {
+		t.Fatalf("expected cycle to have 17 LOCCum, but got %d and %d", a.LOCCum(), b.LOCCum())
+	}
+	if a.Depth() != 1 || a.DepthInternal() != 2 {
+		t.Fatalf("expected cycle to have depth 1 and depth int 2, but got %d and %d", a.Depth(), a.DepthInternal())
+	}
+	if !c.IsCycle() || c.LOCCum() != 5 {
+		t.Fatalf("expected recursive func to be a cycle with 5 LOCCum, but got %v and %d", c.IsCycle(), c.LOCCum())
+	}
+	if ext.IsCycle() || len(a.Cycle()) != 2 {
+		t.Fatalf("unexpected cycles: %v, %v", ext.Cycle(), a.Cycle())
+	}
+}
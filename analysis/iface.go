@@ -0,0 +1,509 @@
+package analysis
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// InterfacesFile is a name of the file, holding interfaces
+// generated by Decouple for a package.
+const InterfacesFile = "interfaces.go"
+
+// Decoupling is a plan of replacing dependency types, used by
+// analyzed packages through a few methods only, with interfaces
+// declared by these packages, see Decouple.
+type Decoupling struct {
+	Interfaces []*Interface
+
+	// Files holds content of new and rewritten files
+	// by their names.
+	Files map[string][]byte
+}
+
+// Interface is a consumer-side interface, covering exactly the methods
+// of the dependency type, used by the package.
+type Interface struct {
+	Pkg  string // import path of the package declaring interface
+	Name string
+	Path string // import path of the dependency
+	Type string // dependency type, like "*bar.Client"
+
+	Methods []string // sorted
+	Decl    string   // Go declaration of the interface
+
+	// Rewritten are params and fields of the package, changed to
+	// the interface, like "Fetch.c" or "Server.client".
+	Rewritten []string
+}
+
+// Decouple finds named types of dependencies, of which analyzed packages
+// call at most maxMethods methods and never access fields, and generates
+// interfaces with these methods in InterfacesFile of every package.
+//
+// If rewrite is true, params of unexported funcs and unexported fields
+// of structs of these types are changed to interfaces too, if they're only
+// used to call methods or assigned to. Funcs used as values, exported funcs,
+// methods and exported fields are never changed, as it could break their
+// users, which may be outside of analyzed packages.
+//
+// Types of packages and selectors, ignored by opts, are skipped.
+//
+// Changed packages are type-checked in memory, and an error is returned
+// if they don't compile. Nothing is written to disk until Write is called.
+func Decouple(ctx context.Context, opts Options, maxMethods int, rewrite bool, patterns ...string) (*Decoupling, error) {
+	// tests may call methods and access fields too
+	opts.Tests = true
+
+	w, results, err := walk(ctx, opts, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Decoupling{Files: make(map[string][]byte)}
+	roots := w.Roots()
+	edited := make(map[*ast.File]bool)
+	for _, pkg := range roots {
+		if strings.HasSuffix(pkg.Name, "_test") {
+			continue
+		}
+		ifaces := interfaces(pkg, results.Result(pkg.PkgPath), maxMethods)
+		if len(ifaces) == 0 {
+			continue
+		}
+
+		filename := filepath.Join(pkgDir(pkg), InterfacesFile)
+		if _, err := os.Stat(filename); err == nil {
+			return nil, fmt.Errorf("file %s already exists", filename)
+		}
+		src, err := interfacesFile(pkg, ifaces)
+		if err != nil {
+			return nil, err
+		}
+		d.Files[filename] = src
+
+		if rewrite {
+			newRewriter(pkg).rewrite(ifaces, edited)
+		}
+		for _, iface := range ifaces {
+			d.Interfaces = append(d.Interfaces, iface.Interface)
+		}
+	}
+	if len(d.Interfaces) == 0 {
+		return d, nil
+	}
+
+	var goVersion string
+	if mod := roots[0].Module; mod != nil {
+		goVersion = mod.GoVersion
+	}
+	edit := func(f *ast.File, pkg *packages.Package) bool {
+		return edited[f]
+	}
+	if err := checkRoots(roots, d.Files, edit, make(map[string]*types.Package), goVersion); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Filenames returns names of new and rewritten files, sorted.
+func (d *Decoupling) Filenames() []string {
+	return filenames(d.Files)
+}
+
+// Write writes new and rewritten files.
+func (d *Decoupling) Write() error {
+	return saveFiles(d.Files)
+}
+
+// candidate is an Interface, being generated for the named type.
+type candidate struct {
+	*Interface
+	named   *types.Named
+	typ     types.Type // named or pointer to it
+	imports map[string]string
+}
+
+// interfaces returns interfaces for dependency types of the package,
+// used through at most maxMethods methods, sorted by name.
+//
+// Only types of dependencies found in result are used, so ignored,
+// stdlib and internal packages are skipped the same way.
+func interfaces(pkg *packages.Package, result *Result, maxMethods int) []*candidate {
+	deps := make(map[string]bool)
+	for _, sel := range result.Selectors {
+		deps[sel.Pkg.Path] = true
+	}
+
+	methods := make(map[*types.Named]map[string]bool)
+	pointer := make(map[*types.Named]bool)
+	fields := make(map[*types.Named]bool)
+	for _, sel := range pkg.TypesInfo.Selections {
+		typ := sel.Recv()
+		if p, ok := typ.(*types.Pointer); ok {
+			typ = p.Elem()
+		}
+		named, ok := typ.(*types.Named)
+		if !ok || named.Obj().Pkg() == nil || !deps[named.Obj().Pkg().Path()] {
+			continue
+		}
+		if types.IsInterface(named) || named.TypeParams() != nil {
+			continue
+		}
+
+		switch sel.Kind() {
+		case types.FieldVal:
+			fields[named] = true
+		case types.MethodVal:
+			if methods[named] == nil {
+				methods[named] = make(map[string]bool)
+			}
+			methods[named][sel.Obj().Name()] = true
+			if _, ok := sel.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer); ok {
+				pointer[named] = true
+			}
+		}
+	}
+
+	var ret []*candidate
+	for named, names := range methods {
+		if fields[named] || len(names) > maxMethods {
+			continue
+		}
+		c := &candidate{
+			Interface: &Interface{
+				Pkg:  pkg.PkgPath,
+				Path: named.Obj().Pkg().Path(),
+			},
+			named:   named,
+			typ:     named,
+			imports: make(map[string]string),
+		}
+		if pointer[named] {
+			c.typ = types.NewPointer(named)
+		}
+		c.Type = types.TypeString(c.typ, func(p *types.Package) string {
+			return p.Name()
+		})
+		for name := range names {
+			c.Methods = append(c.Methods, name)
+		}
+		sort.Strings(c.Methods)
+		ret = append(ret, c)
+	}
+	// names are chosen in a stable order, so the same type
+	// gets the same name in every run
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Path != ret[j].Path {
+			return ret[i].Path < ret[j].Path
+		}
+		return ret[i].named.Obj().Name() < ret[j].named.Obj().Name()
+	})
+
+	taken := make(map[string]bool)
+	var named []*candidate
+	for _, c := range ret {
+		if c.declare(pkg, taken) {
+			named = append(named, c)
+		}
+	}
+	sort.Slice(named, func(i, j int) bool {
+		return named[i].Name < named[j].Name
+	})
+	return named
+}
+
+// declare chooses name of the interface, not used by the package yet,
+// and builds its declaration. It returns false if interface can't be
+// declared, as its methods use unexported types of the dependency.
+func (c *candidate) declare(pkg *packages.Package, taken map[string]bool) bool {
+	obj := c.named.Obj()
+	for _, name := range []string{obj.Name(), strings.ToUpper(obj.Pkg().Name()[:1]) + obj.Pkg().Name()[1:] + obj.Name()} {
+		if !taken[name] && !declared(pkg, name) {
+			c.Name = name
+			break
+		}
+	}
+	if c.Name == "" {
+		return false
+	}
+
+	qualifier := func(p *types.Package) string {
+		if p == pkg.Types {
+			return ""
+		}
+		c.imports[p.Path()] = p.Name()
+		return p.Name()
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// %s is the part of %s (%s), used by the package.\n", c.Name, c.Type, c.Path)
+	fmt.Fprintf(&buf, "type %s interface {\n", c.Name)
+	ms := types.NewMethodSet(c.typ)
+	for _, name := range c.Methods {
+		sel := ms.Lookup(obj.Pkg(), name)
+		if sel == nil {
+			return false
+		}
+		var sig bytes.Buffer
+		types.WriteSignature(&sig, sel.Obj().Type().(*types.Signature), qualifier)
+		if !exportedOnly(sig.String()) {
+			return false
+		}
+		fmt.Fprintf(&buf, "\t%s%s\n", name, sig.String())
+	}
+	buf.WriteString("}")
+
+	c.Decl = buf.String()
+	taken[c.Name] = true
+	return true
+}
+
+// declared returns true if name is declared in the package scope,
+// or in file scope of any of its files, by imports.
+func declared(pkg *packages.Package, name string) bool {
+	if pkg.Types.Scope().Lookup(name) != nil {
+		return true
+	}
+	for _, f := range pkg.Syntax {
+		if scope := pkg.TypesInfo.Scopes[f]; scope != nil && scope.Lookup(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// exportedOnly returns true if signature refers to exported
+// identifiers of other packages only.
+func exportedOnly(sig string) bool {
+	expr, err := parser.ParseExpr("func" + sig)
+	if err != nil {
+		return false
+	}
+	ok := true
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if sel, isSel := n.(*ast.SelectorExpr); isSel && !sel.Sel.IsExported() {
+			ok = false
+		}
+		return ok
+	})
+	return ok
+}
+
+// interfacesFile returns source of InterfacesFile of the package.
+func interfacesFile(pkg *packages.Package, ifaces []*candidate) ([]byte, error) {
+	imports := make(map[string]string)
+	var decls []string
+	for _, c := range ifaces {
+		for path, name := range c.imports {
+			imports[path] = name
+		}
+		decls = append(decls, c.Decl)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\n", pkg.Name)
+	if len(imports) > 0 {
+		var paths []string
+		for path := range imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		buf.WriteString("import (\n")
+		for _, path := range paths {
+			if filepath.Base(path) != imports[path] {
+				fmt.Fprintf(&buf, "%s ", imports[path])
+			}
+			fmt.Fprintf(&buf, "%s\n", strconv.Quote(path))
+		}
+		buf.WriteString(")\n\n")
+	}
+	buf.WriteString(strings.Join(decls, "\n\n"))
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", pkg.PkgPath, err)
+	}
+	return src, nil
+}
+
+// rewriter changes params and fields of the package to interfaces.
+type rewriter struct {
+	pkg  *packages.Package
+	uses map[types.Object][]*ast.Ident
+
+	// valid are uses of params and fields, which stay valid
+	// when their type is changed to interface: method calls
+	// and assignments
+	valid map[*ast.Ident]bool
+	calls map[*ast.Ident]bool // funcs being called
+}
+
+func newRewriter(pkg *packages.Package) *rewriter {
+	r := &rewriter{
+		pkg:   pkg,
+		uses:  make(map[types.Object][]*ast.Ident),
+		valid: make(map[*ast.Ident]bool),
+		calls: make(map[*ast.Ident]bool),
+	}
+	for ident, obj := range pkg.TypesInfo.Uses {
+		r.uses[obj] = append(r.uses[obj], ident)
+	}
+
+	for _, f := range pkg.Syntax {
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.SelectorExpr:
+				if sel := pkg.TypesInfo.Selections[n]; sel != nil && sel.Kind() == types.MethodVal {
+					r.mark(r.valid, n.X)
+				}
+			case *ast.CallExpr:
+				r.mark(r.calls, n.Fun)
+			case *ast.AssignStmt:
+				if n.Tok == token.ASSIGN {
+					for _, lhs := range n.Lhs {
+						r.mark(r.valid, lhs)
+					}
+				}
+			case *ast.KeyValueExpr:
+				if key, ok := n.Key.(*ast.Ident); ok {
+					r.valid[key] = true
+				}
+			}
+			return true
+		})
+	}
+	return r
+}
+
+// mark adds identifier of the variable, field or func,
+// denoted by expression, to set.
+func (r *rewriter) mark(set map[*ast.Ident]bool, expr ast.Expr) {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.Ident:
+		set[e] = true
+	case *ast.SelectorExpr:
+		set[e.Sel] = true
+	}
+}
+
+// rewrite changes types of params and fields to interfaces,
+// marking changed files as edited.
+func (r *rewriter) rewrite(ifaces []*candidate, edited map[*ast.File]bool) {
+	for _, f := range r.pkg.Syntax {
+		if strings.HasSuffix(r.pkg.Fset.File(f.Pos()).Name(), "_test.go") {
+			continue
+		}
+
+		var changed bool
+		for _, decl := range f.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				fn := r.pkg.TypesInfo.Defs[decl.Name]
+				if decl.Recv != nil || fn == nil || fn.Exported() || !r.onlyCalled(fn) {
+					continue
+				}
+				for _, field := range decl.Type.Params.List {
+					changed = r.field(ifaces, decl.Name.Name, field) || changed
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					spec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						continue
+					}
+					if st, ok := spec.Type.(*ast.StructType); ok && spec.TypeParams == nil {
+						for _, field := range st.Fields.List {
+							changed = r.field(ifaces, spec.Name.Name, field) || changed
+						}
+					}
+				}
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		edited[f] = true
+		for _, c := range ifaces {
+			for _, spec := range f.Imports {
+				p, _ := strconv.Unquote(spec.Path.Value)
+				if p != c.Path {
+					continue
+				}
+				var name string
+				if spec.Name != nil {
+					name = spec.Name.Name
+				}
+				if !astutil.UsesImport(f, p) {
+					astutil.DeleteNamedImport(r.pkg.Fset, f, name, p)
+				}
+				break
+			}
+		}
+	}
+}
+
+// field changes type of param or struct field to interface, if all
+// its names are used only in a way valid for interface. It returns
+// true if field was changed.
+func (r *rewriter) field(ifaces []*candidate, owner string, field *ast.Field) bool {
+	if len(field.Names) == 0 {
+		return false
+	}
+	typ := r.pkg.TypesInfo.TypeOf(field.Type)
+	var iface *candidate
+	for _, c := range ifaces {
+		if types.Identical(typ, c.typ) || types.Identical(typ, types.NewPointer(c.named)) {
+			iface = c
+			break
+		}
+	}
+	if iface == nil {
+		return false
+	}
+
+	for _, name := range field.Names {
+		obj, ok := r.pkg.TypesInfo.Defs[name].(*types.Var)
+		if !ok || name.Name == "_" || (obj.IsField() && obj.Exported()) {
+			return false
+		}
+		for _, ident := range r.uses[obj] {
+			if !r.valid[ident] {
+				return false
+			}
+		}
+	}
+
+	field.Type = ast.NewIdent(iface.Name)
+	for _, name := range field.Names {
+		iface.Rewritten = append(iface.Rewritten, owner+"."+name.Name)
+	}
+	return true
+}
+
+// onlyCalled returns true if func is never used as a value in the
+// package, so changing its params can't break its users, as long
+// as it's unexported.
+func (r *rewriter) onlyCalled(fn types.Object) bool {
+	for _, ident := range r.uses[fn] {
+		if !r.calls[ident] {
+			return false
+		}
+	}
+	return true
+}
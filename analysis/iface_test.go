@@ -0,0 +1,165 @@
+package analysis
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecouple(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/dec\n\ngo 1.25\n",
+		"bar/bar.go": `package bar
+
+type Client struct{ addr string }
+
+func New(addr string) *Client { return &Client{addr} }
+
+func (c *Client) Get(key string) (string, error) { return c.addr + key, nil }
+func (c *Client) Close() error                   { return nil }
+func (c *Client) Stats() Stats                   { return Stats{} }
+
+type Stats struct{ Calls int }
+
+type Config struct{ Name string }
+
+func (c Config) String() string { return c.Name }
+
+type Pool struct{}
+
+func (Pool) A() {}
+func (Pool) B() {}
+func (Pool) C() {}
+func (Pool) D() {}
+`,
+		"app/app.go": `package app
+
+import "example.com/dec/bar"
+
+type Server struct {
+	client *bar.Client
+	cfg    bar.Config
+}
+
+func NewServer(addr string) *Server {
+	return &Server{client: bar.New(addr)}
+}
+
+func (s *Server) Close() error {
+	return s.client.Close()
+}
+
+func Fetch(c *bar.Client, key string) (string, error) {
+	return fetch(c, key)
+}
+
+func fetch(c *bar.Client, key string) (string, error) {
+	return c.Get(key)
+}
+
+func keep(c *bar.Client) *bar.Client {
+	return c
+}
+
+func Describe(cfg bar.Config) string {
+	return cfg.Name + cfg.String()
+}
+
+func Use(p bar.Pool) {
+	p.A()
+	p.B()
+	p.C()
+	p.D()
+}
+`,
+		"app/app_test.go": `package app
+
+import "testing"
+
+func TestServer(t *testing.T) {
+	s := NewServer("x")
+	if s.client.Stats().Calls != 0 {
+		t.Fatal("expected no calls")
+	}
+}
+`,
+	})
+
+	ctx := context.Background()
+	ignore := IgnoreList{Packages: []string{"example.com/dec/bar"}}
+	d, err := Decouple(ctx, Options{Dir: dir, Internal: true, Ignore: ignore}, 3, true, "./app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Interfaces) != 0 {
+		t.Fatalf("expected no interfaces for ignored package, but got %d", len(d.Interfaces))
+	}
+
+	d, err = Decouple(ctx, Options{Dir: dir, Internal: true}, 3, true, "./app")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Config fields are accessed, and Pool has too many methods used;
+	// methods, called by tests, are needed too
+	if len(d.Interfaces) != 1 {
+		t.Fatalf("expected 1 interface, but got %d", len(d.Interfaces))
+	}
+	iface := d.Interfaces[0]
+	if iface.Name != "Client" || iface.Type != "*bar.Client" || iface.Path != "example.com/dec/bar" {
+		t.Fatalf("unexpected interface %s for %s (%s)", iface.Name, iface.Type, iface.Path)
+	}
+	if got := strings.Join(iface.Methods, ","); got != "Close,Get,Stats" {
+		t.Fatalf("expected methods Close,Get,Stats, but got %s", got)
+	}
+	// keep returns its param, and Fetch is exported, so they're not changed
+	if got := strings.Join(iface.Rewritten, ","); got != "Server.client,fetch.c" {
+		t.Fatalf("expected Server.client,fetch.c to be rewritten, but got %s", got)
+	}
+
+	generated := string(d.Files[filepath.Join(dir, "app", InterfacesFile)])
+	for _, s := range []string{
+		"package app",
+		"// Client is the part of *bar.Client (example.com/dec/bar), used by the package.",
+		"\t\"example.com/dec/bar\"\n",
+		"\tClose() error\n\tGet(key string) (string, error)\n\tStats() bar.Stats\n",
+	} {
+		if !strings.Contains(generated, s) {
+			t.Fatalf("expected generated code to contain %q, but got:\n%s", s, generated)
+		}
+	}
+	rewritten := string(d.Files[filepath.Join(dir, "app", "app.go")])
+	for _, s := range []string{
+		"client Client\n",
+		"func Fetch(c *bar.Client, key string)",
+		"func fetch(c Client, key string)",
+		"func keep(c *bar.Client) *bar.Client",
+	} {
+		if !strings.Contains(rewritten, s) {
+			t.Fatalf("expected rewritten code to contain %q, but got:\n%s", s, rewritten)
+		}
+	}
+
+	if err := d.Write(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(ctx, dir, true, "./..."); err != nil {
+		t.Fatalf("expected module to compile after rewriting: %v", err)
+	}
+
+	// methods of Client are called through interface now
+	d, err = Decouple(ctx, Options{Dir: dir, Internal: true}, 3, true, "./app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Interfaces) != 0 {
+		t.Fatalf("expected no interfaces after rewriting, but got %d", len(d.Interfaces))
+	}
+
+	// generated file is never overwritten
+	if _, err := Decouple(ctx, Options{Dir: dir, Internal: true}, 4, false, "./app"); err == nil {
+		t.Fatal("expected decoupling to fail for existing interfaces file")
+	}
+}
@@ -0,0 +1,382 @@
+package analysis
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// SchemaVersion is a version of JSON output schema.
+//
+// It's increased on every incompatible change (removed or
+// renamed fields, changed meaning of values). New fields may
+// be added without bumping the version, so consumers should
+// ignore unknown fields.
+const SchemaVersion = 2
+
+// JSONReport is a top-level object of JSON output (-format=json).
+type JSONReport struct {
+	SchemaVersion int    `json:"schema_version"`
+	Package       string `json:"package"`
+
+	Totals      JSONTotals        `json:"totals"`
+	Selectors   []JSONSelector    `json:"selectors"`
+	Deps        []JSONSelector    `json:"deps"`
+	Packages    []JSONPackageStat `json:"packages"`
+	Suggestions []JSONSuggestion  `json:"suggestions"`
+	Violations  []JSONViolation   `json:"violations"`
+
+	// BlankImports holds packages, imported with blank imports.
+	BlankImports []JSONPackage `json:"blank_imports"`
+
+	// Cycles holds IDs of mutually recursive selectors.
+	Cycles [][]string `json:"cycles"`
+
+	// Set only if compared to baseline.
+	Regressions *JSONRegressions `json:"regressions,omitempty"`
+
+	// Analyzed holds per-package stats for each of our packages,
+	// while all other fields are aggregated across all of them.
+	Analyzed []JSONAnalyzedPackage `json:"analyzed"`
+}
+
+// JSONAnalyzedPackage holds stats for one of our packages.
+type JSONAnalyzedPackage struct {
+	Path     string            `json:"path"`
+	Totals   JSONTotals        `json:"totals"`
+	Packages []JSONPackageStat `json:"packages"`
+}
+
+// JSONPackage describes imported package.
+type JSONPackage struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Module  string `json:"module,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// JSONSelector describes a single selector (func, method, type, etc).
+//
+// Deps holds IDs of selectors it depends on, each of them could be
+// found either in Selectors or Deps lists of the report.
+type JSONSelector struct {
+	ID      string      `json:"id"`
+	Package JSONPackage `json:"package"`
+	Name    string      `json:"name"`
+	Recv    string      `json:"recv,omitempty"`
+	Type    string      `json:"type"`
+
+	// Count is a number of usages in the analyzed package,
+	// it's zero for transitive deps.
+	Count int `json:"count"`
+
+	LOC       int `json:"loc"`
+	LOCCum    int `json:"loc_cum"`
+	LOCUnique int `json:"loc_unique"`
+	SLOC      int `json:"sloc"`
+	SLOCCum   int `json:"sloc_cum"`
+
+	// Applies for functions and methods only.
+	Stmts         int `json:"stmts"`
+	StmtsCum      int `json:"stmts_cum"`
+	Cyclomatic    int `json:"cyclomatic"`
+	Cognitive     int `json:"cognitive"`
+	Depth         int `json:"depth"`
+	DepthInternal int `json:"depth_internal"`
+
+	Deps []string `json:"deps"`
+}
+
+// JSONPackageStat holds stats for a single imported package.
+type JSONPackageStat struct {
+	JSONPackage
+
+	Count         int `json:"count"`
+	Calls         int `json:"calls"`
+	LOCCum        int `json:"loc_cum"`
+	LOCUnique     int `json:"loc_unique"`
+	SLOCCum       int `json:"sloc_cum"`
+	StmtsCum      int `json:"stmts_cum"`
+	Cyclomatic    int `json:"cyclomatic"`
+	CyclomaticMax int `json:"cyclomatic_max"`
+	Cognitive     int `json:"cognitive"`
+	CognitiveMax  int `json:"cognitive_max"`
+	Depth         int `json:"depth"`
+	DepthInternal int `json:"depth_internal"`
+	InitLOCCum    int `json:"init_loc_cum"`
+
+	// UsedBy holds import paths of our packages, using this one.
+	UsedBy []string `json:"used_by,omitempty"`
+}
+
+// JSONSuggestion describes a package, suggested for removing.
+type JSONSuggestion struct {
+	JSONPackage
+
+	LOCCum        int `json:"loc_cum"`
+	SLOCCum       int `json:"sloc_cum"`
+	StmtsCum      int `json:"stmts_cum"`
+	Count         int `json:"count"`
+	DepthInternal int `json:"depth_internal"`
+}
+
+// JSONViolation describes a policy rule, fired for the package.
+type JSONViolation struct {
+	JSONPackage
+
+	Rule   string `json:"rule"`
+	Reason string `json:"reason"`
+}
+
+// JSONRegressions describes changes compared to baseline.
+type JSONRegressions struct {
+	NewPackages  []JSONPackage `json:"new_packages"`
+	NewSelectors []string      `json:"new_selectors"`
+	Grown        []JSONGrowth  `json:"grown"`
+}
+
+// JSONGrowth describes package with grown stats.
+type JSONGrowth struct {
+	JSONPackage
+
+	LOCCum           int `json:"loc_cum"`
+	OldLOCCum        int `json:"old_loc_cum"`
+	Depth            int `json:"depth"`
+	OldDepth         int `json:"old_depth"`
+	DepthInternal    int `json:"depth_internal"`
+	OldDepthInternal int `json:"old_depth_internal"`
+}
+
+// JSONTotals holds total stats for all packages.
+type JSONTotals struct {
+	Packages      int `json:"packages"`
+	LOC           int `json:"loc"`
+	LOCUnique     int `json:"loc_unique"`
+	Calls         int `json:"calls"`
+	Depth         int `json:"depth"`
+	DepthInternal int `json:"depth_internal"`
+	InitLOC       int `json:"init_loc"`
+}
+
+// JSON builds JSON report for the Result.
+//
+// Suggestions are included only if suggest is true.
+func (r *Result) JSON(pkg string, suggest bool) *JSONReport {
+	report := &JSONReport{
+		SchemaVersion: SchemaVersion,
+		Package:       pkg,
+		Totals:        jsonTotals(r.Totals(pkg)),
+		Selectors:     []JSONSelector{},
+		Deps:          []JSONSelector{},
+		Packages:      []JSONPackageStat{},
+		Suggestions:   []JSONSuggestion{},
+		Violations:    []JSONViolation{},
+		BlankImports:  []JSONPackage{},
+		Cycles:        [][]string{},
+		Analyzed:      []JSONAnalyzedPackage{},
+	}
+
+	selectors := r.All()
+	sort.Sort(ByID(selectors))
+
+	seen := make(map[string]bool)
+	for _, sel := range selectors {
+		seen[sel.ID()] = true
+		report.Selectors = append(report.Selectors, r.jsonSelector(sel))
+	}
+
+	// collect transitive deps, breadth-first
+	var deps []*Selector
+	queue := selectors
+	for len(queue) > 0 {
+		sel := queue[0]
+		queue = queue[1:]
+		for _, dep := range sel.Deps {
+			if seen[dep.ID()] {
+				continue
+			}
+			seen[dep.ID()] = true
+			deps = append(deps, dep)
+			queue = append(queue, dep)
+		}
+	}
+	sort.Sort(ByID(deps))
+	for _, dep := range deps {
+		report.Deps = append(report.Deps, r.jsonSelector(dep))
+	}
+
+	for _, stat := range r.PackagesStats() {
+		report.Packages = append(report.Packages, jsonPackageStat(stat))
+		if stat.Blank {
+			report.BlankImports = append(report.BlankImports, jsonPackage(*stat.Package))
+		}
+	}
+
+	for _, cycle := range r.Cycles() {
+		var ids []string
+		for _, sel := range cycle {
+			ids = append(ids, sel.ID())
+		}
+		report.Cycles = append(report.Cycles, ids)
+	}
+
+	if reg := r.Regressions; reg != nil {
+		report.Regressions = &JSONRegressions{
+			NewPackages:  []JSONPackage{},
+			NewSelectors: []string{},
+			Grown:        []JSONGrowth{},
+		}
+		for _, stat := range reg.NewPackages {
+			report.Regressions.NewPackages = append(report.Regressions.NewPackages, jsonPackage(*stat.Package))
+		}
+		for _, sel := range reg.NewSelectors {
+			report.Regressions.NewSelectors = append(report.Regressions.NewSelectors, sel.ID())
+		}
+		for _, g := range reg.Grown {
+			report.Regressions.Grown = append(report.Regressions.Grown, JSONGrowth{
+				JSONPackage:      jsonPackage(*g.Package.Package),
+				LOCCum:           g.Package.LOCCum,
+				OldLOCCum:        g.Old.LOCCum,
+				Depth:            g.Package.Depth,
+				OldDepth:         g.Old.Depth,
+				DepthInternal:    g.Package.DepthInternal,
+				OldDepthInternal: g.Old.DepthInternal,
+			})
+		}
+	}
+
+	if suggest {
+		for _, stat := range r.Candidates() {
+			report.Suggestions = append(report.Suggestions, JSONSuggestion{
+				JSONPackage:   jsonPackage(*stat.Package),
+				LOCCum:        stat.LOCCum,
+				SLOCCum:       stat.SLOCCum,
+				StmtsCum:      stat.StmtsCum,
+				Count:         stat.DepsCount,
+				DepthInternal: stat.DepthInternal,
+			})
+		}
+	}
+
+	return report
+}
+
+// JSON builds JSON report for the aggregate Result, with
+// per-package stats and users of each dependency.
+func (rs *Results) JSON(pkg string, suggest bool) *JSONReport {
+	report := rs.Aggregate.JSON(pkg, suggest)
+
+	usedBy := rs.UsedBy()
+	for i, p := range report.Packages {
+		report.Packages[i].UsedBy = usedBy[jsonPackageID(p.JSONPackage)]
+	}
+
+	for _, path := range rs.Packages {
+		r := rs.Results[path]
+		analyzed := JSONAnalyzedPackage{
+			Path:     path,
+			Totals:   jsonTotals(r.Totals(path)),
+			Packages: []JSONPackageStat{},
+		}
+		for _, stat := range r.PackagesStats() {
+			analyzed.Packages = append(analyzed.Packages, jsonPackageStat(stat))
+		}
+		report.Analyzed = append(report.Analyzed, analyzed)
+	}
+
+	return report
+}
+
+// AddViolations adds policy violations to the report.
+func (report *JSONReport) AddViolations(violations []Violation) {
+	for _, v := range violations {
+		report.Violations = append(report.Violations, JSONViolation{
+			JSONPackage: jsonPackage(*v.Package.Package),
+			Rule:        v.Rule.String(),
+			Reason:      v.Reason,
+		})
+	}
+}
+
+// Write writes indented JSON report to w.
+func (report *JSONReport) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+func (r *Result) jsonSelector(sel *Selector) JSONSelector {
+	ret := JSONSelector{
+		ID:      sel.ID(),
+		Package: jsonPackage(sel.Pkg),
+		Name:    sel.Name,
+		Recv:    sel.Recv,
+		Type:    sel.Type,
+		Count:   r.Counter[sel.ID()],
+
+		LOC:       sel.LOC,
+		LOCCum:    sel.LOCCum(),
+		LOCUnique: sel.LOCUnique(),
+		SLOC:      sel.SLOC,
+		SLOCCum:   sel.SLOCCum(),
+
+		Deps: []string{},
+	}
+	if sel.IsFunc() {
+		ret.Stmts = sel.Stmts
+		ret.StmtsCum = sel.StmtsCum()
+		ret.Cyclomatic = sel.Cyclomatic
+		ret.Cognitive = sel.Cognitive
+		ret.Depth = sel.Depth()
+		ret.DepthInternal = sel.DepthInternal()
+	}
+	for _, dep := range sel.Deps {
+		ret.Deps = append(ret.Deps, dep.ID())
+	}
+	sort.Strings(ret.Deps)
+	return ret
+}
+
+func jsonPackageStat(stat *PackageStat) JSONPackageStat {
+	return JSONPackageStat{
+		JSONPackage:   jsonPackage(*stat.Package),
+		Count:         stat.DepsCount,
+		Calls:         stat.DepsCallsCount,
+		LOCCum:        stat.LOCCum,
+		LOCUnique:     stat.LOCUnique,
+		SLOCCum:       stat.SLOCCum,
+		StmtsCum:      stat.StmtsCum,
+		Cyclomatic:    stat.Cyclomatic,
+		CyclomaticMax: stat.CyclomaticMax,
+		Cognitive:     stat.Cognitive,
+		CognitiveMax:  stat.CognitiveMax,
+		Depth:         stat.Depth,
+		DepthInternal: stat.DepthInternal,
+		InitLOCCum:    stat.InitLOCCum,
+	}
+}
+
+func jsonTotals(t *Totals) JSONTotals {
+	return JSONTotals{
+		Packages:      t.Packages,
+		LOC:           t.LOC,
+		LOCUnique:     t.LOCUnique,
+		Calls:         t.Calls,
+		Depth:         t.Depth,
+		DepthInternal: t.DepthInternal,
+		InitLOC:       t.InitLOC,
+	}
+}
+
+func jsonPackageID(p JSONPackage) string {
+	return Package{Name: p.Name, Path: p.Path, Module: p.Module, Version: p.Version}.ID()
+}
+
+func jsonPackage(pkg Package) JSONPackage {
+	return JSONPackage{
+		Name:    pkg.Name,
+		Path:    pkg.Path,
+		Module:  pkg.Module,
+		Version: pkg.Version,
+	}
+}
@@ -0,0 +1,55 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	src := "test/exported.go"
+	result := getResult(t, true, src)
+
+	var buf bytes.Buffer
+	if err := result.JSON("test", true).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var report JSONReport
+	if err := json.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("%s: invalid JSON output: %v", src, err)
+	}
+
+	if report.SchemaVersion != SchemaVersion {
+		t.Fatalf("%s: expected schema version %d, but got %d", src, SchemaVersion, report.SchemaVersion)
+	}
+	if len(report.Selectors) != 2 {
+		t.Fatalf("%s: expected to have 2 selectors, but have %d", src, len(report.Selectors))
+	}
+
+	fn := report.Selectors[0]
+	if fn.ID != samplePkg+".func.SampleFunc" || fn.Count != 1 || fn.LOC != 6 || fn.LOCCum != 14 {
+		t.Fatalf("%s: unexpected selector in JSON: %+v", src, fn)
+	}
+	if len(fn.Deps) != 1 || fn.Deps[0] != samplePkg+".func.Xfunc" {
+		t.Fatalf("%s: expected SampleFunc to depend on Xfunc, but got %v", src, fn.Deps)
+	}
+
+	// Xfunc and YFunc are reachable only through SampleFunc
+	if len(report.Deps) != 2 {
+		t.Fatalf("%s: expected to have 2 transitive deps, but have %d", src, len(report.Deps))
+	}
+	if report.Deps[1].Count != 0 {
+		t.Fatalf("%s: expected transitive dep to have zero count, but got %d", src, report.Deps[1].Count)
+	}
+
+	if report.Totals.Packages != 1 || report.Totals.LOC != 15 {
+		t.Fatalf("%s: unexpected totals in JSON: %+v", src, report.Totals)
+	}
+	if len(report.Packages) != 1 || report.Packages[0].Path != "github.com/divan/depscheck/test/sample" {
+		t.Fatalf("%s: unexpected packages in JSON: %+v", src, report.Packages)
+	}
+	if len(report.Suggestions) != 1 {
+		t.Fatalf("%s: expected to have 1 suggestion, but have %d", src, len(report.Suggestions))
+	}
+}
@@ -0,0 +1,170 @@
+package analysis
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// LoadMode is a set of information requested from go/packages.
+//
+// Deps are loaded from source as well, because we need their
+// AST and type info to walk into used functions.
+const LoadMode = packages.NeedName |
+	packages.NeedFiles |
+	packages.NeedImports |
+	packages.NeedDeps |
+	packages.NeedTypes |
+	packages.NeedSyntax |
+	packages.NeedTypesInfo |
+	packages.NeedModule
+
+// Load loads packages matching given patterns, resolving them
+// from dir (current directory, if empty).
+//
+// Patterns are anything understood by 'go list': import paths,
+// relative paths, "./..." or a list of .go files. Module mode,
+// go.work workspaces and replace directives are handled by the
+// go command itself.
+//
+// Errors of all packages are returned as a single error,
+// listing each of them.
+func Load(ctx context.Context, dir string, tests bool, patterns ...string) ([]*packages.Package, error) {
+	return load(ctx, Options{Dir: dir, Tests: tests}, patterns...)
+}
+
+// load implements Load, skipping function bodies of dependencies,
+// which are not walked, if opts.Cache is set.
+func load(ctx context.Context, opts Options, patterns ...string) ([]*packages.Package, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	dir := opts.Dir
+	cfg := &packages.Config{
+		Context: ctx,
+		Mode:    LoadMode,
+		Dir:     dir,
+		Tests:   opts.Tests,
+	}
+	var t *trimmer
+	if opts.Cache != nil {
+		// find initial packages first, as they're never trimmed
+		initial, err := packages.Load(&packages.Config{Context: ctx, Mode: packages.NeedFiles, Dir: dir, Tests: opts.Tests}, patterns...)
+		if err != nil {
+			return nil, err
+		}
+		t = &trimmer{
+			cache:   opts.Cache,
+			stdlib:  opts.Stdlib,
+			initial: make(map[string]bool),
+			trimmed: make(map[string]bool),
+		}
+		for _, pkg := range initial {
+			t.initial[pkgDir(pkg)] = true
+		}
+		cfg.ParseFile = t.parseFile
+	}
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err := ctx.Err(); err != nil {
+		// go/packages doesn't always wrap it
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages found for %v", patterns)
+	}
+
+	var errs []error
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		trimmed := t != nil && t.isTrimmed(pkg)
+		for _, err := range pkg.Errors {
+			if trimmed && err.Kind == packages.TypeError {
+				continue
+			}
+			errs = append(errs, err)
+		}
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("failed to load packages: %d error(s):\n%w", len(errs), errors.Join(errs...))
+	}
+
+	resolveAdHoc(ctx, dir, pkgs)
+
+	return pkgs, nil
+}
+
+// adHocPath is an import path the go command gives to packages
+// made of a list of .go files.
+const adHocPath = "command-line-arguments"
+
+// resolveAdHoc replaces import path and module of ad-hoc packages
+// with the path of their directory within the main module, so they
+// could be compared with their imports. It's a noop outside of module mode.
+func resolveAdHoc(ctx context.Context, dir string, pkgs []*packages.Package) {
+	var modules map[string]*packages.Module
+	for _, pkg := range pkgs {
+		if pkg.PkgPath != adHocPath || len(pkg.GoFiles) == 0 {
+			continue
+		}
+
+		if modules == nil {
+			var err error
+			if modules, err = listModules(ctx, dir); err != nil {
+				return
+			}
+		}
+
+		// the innermost module wins for nested modules in workspace
+		pkgDir := filepath.Dir(pkg.GoFiles[0])
+		var bestDir string
+		for _, mod := range modules {
+			rel, err := filepath.Rel(mod.Dir, pkgDir)
+			if err != nil || strings.HasPrefix(rel, "..") || len(mod.Dir) < len(bestDir) {
+				continue
+			}
+			bestDir = mod.Dir
+			pkg.Module = mod
+			pkg.PkgPath = mod.Path
+			if rel != "." {
+				pkg.PkgPath += "/" + filepath.ToSlash(rel)
+			}
+		}
+	}
+}
+
+// listModules returns main modules (more than one in go.work workspace)
+// for the given dir, keyed by module path.
+func listModules(ctx context.Context, dir string) (map[string]*packages.Module, error) {
+	cmd := exec.CommandContext(ctx, "go", "list", "-m", "-f", "{{.Path}}\t{{.Dir}}\t{{.GoMod}}")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	modules := make(map[string]*packages.Module)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 || fields[1] == "" {
+			continue
+		}
+		modules[fields[0]] = &packages.Module{
+			Path:  fields[0],
+			Dir:   fields[1],
+			GoMod: fields[2],
+			Main:  true,
+		}
+	}
+	return modules, scanner.Err()
+}
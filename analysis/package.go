@@ -1,16 +1,25 @@
-package main
+package analysis
 
 import (
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
 )
 
 // Package represents package info, needed for this tool.
 type Package struct {
 	Name string
 	Path string
+
+	// Module path and version of the module, owning this
+	// package. Both are empty for stdlib and GOPATH packages,
+	// Version is also empty for main and locally replaced modules.
+	Module  string
+	Version string
 }
 
 // NewPackage creates new Package.
@@ -21,45 +30,70 @@ func NewPackage(name, path string) Package {
 	}
 }
 
-func init() {
-	// Try to load list of std packages from goroot
-	getStdPkgs()
+// PackageOf creates new Package from the loaded package info,
+// including its module.
+func PackageOf(pkg *packages.Package) Package {
+	p := NewPackage(pkg.Name, pkg.PkgPath)
+	if mod := pkg.Module; mod != nil {
+		p.Module = mod.Path
+		p.Version = mod.Version
+		if mod.Replace != nil && mod.Replace.Version != "" {
+			p.Version = mod.Replace.Version
+		}
+	}
+	return p
+}
+
+// ID returns unique identifier of the package: its import
+// path, with module version inserted after module path, like
+// "golang.org/x/tools@v0.1.0/go/packages". Name of the package
+// is not unique and should be used only for display.
+func (p Package) ID() string {
+	if p.Module == "" || p.Version == "" || !strings.HasPrefix(p.Path, p.Module) {
+		return p.Path
+	}
+	return p.Module + "@" + p.Version + strings.TrimPrefix(p.Path, p.Module)
+}
+
+// ModuleString returns module of the package in "path@version" form.
+func (p Package) ModuleString() string {
+	if p.Version == "" {
+		return p.Module
+	}
+	return p.Module + "@" + p.Version
 }
 
-// IsInternal returns true if subpkg is a subpackage of
-// pkg.
-func IsInternal(pkg, subpkg string) bool {
+// IsInternal returns true if dep belongs to the same project
+// as pkg.
+//
+// Packages are classified by their owning module: dep is internal
+// if it's in the same module as pkg, or if both are in main modules
+// (more than one for go.work workspaces). Without module info
+// (GOPATH mode) dep is internal only if it's a subpackage of pkg.
+func IsInternal(pkg, dep *packages.Package) bool {
 	// Skip if any is stdlib
-	if IsStdlib(pkg) || IsStdlib(subpkg) {
+	if IsStdlib(pkg.PkgPath) || IsStdlib(dep.PkgPath) {
 		return false
 	}
 
-	// Or it is submodule
-	if strings.HasPrefix(subpkg, pkg+"/") {
-		return true
+	mod, depMod := pkg.Module, dep.Module
+	if mod == nil || depMod == nil {
+		return strings.HasPrefix(dep.PkgPath, pkg.PkgPath+"/")
 	}
 
-	// Or it is on same repo nesting level (nesting > 2)
-	// FIXME: this code assumes layout "server/user/repo",
-	// for non-standard layouts ("gopkg.in/music.v0") it'll
-	// report false negative.
-	if i := strings.Count(pkg, "/"); i > 2 {
-		if strings.HasPrefix(subpkg, pkg[0:i]) {
-			return true
-		}
+	if mod.Path == depMod.Path {
+		return true
 	}
 
-	return false
+	return mod.Main && depMod.Main
 }
 
 // IsStdlib attempts to check if package belongs to stdlib.
+//
+// List of stdlib packages is loaded from GOROOT on first call.
 func IsStdlib(path string) bool {
-	for _, p := range stdPkgs {
-		if p == path {
-			return true
-		}
-	}
-	return false
+	stdPkgsOnce.Do(getStdPkgs)
+	return stdPkgs[path]
 }
 
 // getStdPkgs tries to get list of stdlib packages by reading GOROOT
@@ -88,15 +122,23 @@ func getStdPkgs() {
 			return filepath.SkipDir
 		}
 
-		stdPkgs = append(stdPkgs, name)
+		stdPkgs[name] = true
 		return nil
 	}
+
+	stdPkgs = make(map[string]bool)
 	if err := filepath.Walk(src, walkFn); err != nil {
-		stdPkgs = stdPkgsDefault
+		stdPkgs = make(map[string]bool)
+		for _, name := range stdPkgsDefault {
+			stdPkgs[name] = true
+		}
 	}
 }
 
-var stdPkgs []string
+var (
+	stdPkgs     map[string]bool
+	stdPkgsOnce sync.Once
+)
 
 var stdPkgsDefault = []string{
 	"archive/tar",
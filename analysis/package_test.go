@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+func TestPackageChecks(t *testing.T) {
+	var pkg, subpkg *packages.Package
+
+	checkResult := func(pkg, subpkg *packages.Package, want bool) {
+		got := IsInternal(pkg, subpkg)
+		if got != want {
+			t.Fatalf("Expecting IsInternal to return %v in this case: (%s, %s)", want, pkg.PkgPath, subpkg.PkgPath)
+		}
+	}
+
+	mainMod := &packages.Module{Path: "github.com/divan/depscheck", Main: true}
+	music := &packages.Module{Path: "gopkg.in/music.v0", Version: "v0.1.0"}
+	musicExt := &packages.Module{Path: "gopkg.in/music.v0/ext", Version: "v0.2.0"}
+	vanity := &packages.Module{Path: "go.example.com/tools", Version: "v1.0.0"}
+	work := &packages.Module{Path: "example.com/workspace/other", Main: true}
+
+	pkg, subpkg = newPkg("github.com/divan/depscheck", mainMod), newPkg("github.com/divan/depscheck/foo", mainMod)
+	checkResult(pkg, subpkg, true)
+	pkg, subpkg = newPkg("github.com/divan/depscheck/bar", mainMod), newPkg("github.com/divan/depscheck/foo", mainMod)
+	checkResult(pkg, subpkg, true)
+	pkg, subpkg = newPkg("github.com/divan/depscheck", mainMod), newPkg("gopkg.in/music.v0", music)
+	checkResult(pkg, subpkg, false)
+	pkg, subpkg = newPkg("gopkg.in/music.v0", music), newPkg("gopkg.in/music.v0/notes", music)
+	checkResult(pkg, subpkg, true)
+	pkg, subpkg = newPkg("gopkg.in/music.v0", music), newPkg("gopkg.in/music.v0/ext", musicExt)
+	checkResult(pkg, subpkg, false)
+	pkg, subpkg = newPkg("github.com/divan/depscheck", mainMod), newPkg("go.example.com/tools/log", vanity)
+	checkResult(pkg, subpkg, false)
+	pkg, subpkg = newPkg("github.com/divan/depscheck", mainMod), newPkg("example.com/workspace/other/pkg", work)
+	checkResult(pkg, subpkg, true)
+	pkg, subpkg = newPkg("github.com/divan/depscheck", mainMod), newPkg("strings", nil)
+	checkResult(pkg, subpkg, false)
+
+	// GOPATH mode, without module info
+	pkg, subpkg = newPkg("github.com/divan/depscheck", nil), newPkg("github.com/divan/depscheck/foo", nil)
+	checkResult(pkg, subpkg, true)
+	pkg, subpkg = newPkg("github.com/divan/package1", nil), newPkg("github.com/divan/package2", nil)
+	checkResult(pkg, subpkg, false)
+}
+
+func TestPackageOf(t *testing.T) {
+	pkg := newPkg("gopkg.in/music.v0/notes", &packages.Module{
+		Path:    "gopkg.in/music.v0",
+		Version: "v0.1.0",
+		Replace: &packages.Module{Path: "github.com/fork/music", Version: "v0.1.1"},
+	})
+	p := PackageOf(pkg)
+	if p.Module != "gopkg.in/music.v0" || p.Version != "v0.1.1" {
+		t.Fatalf("Expecting replaced module version, but got %s@%s", p.Module, p.Version)
+	}
+	if got, want := p.ModuleString(), "gopkg.in/music.v0@v0.1.1"; got != want {
+		t.Fatalf("Expecting ModuleString to be %q, but got %q", want, got)
+	}
+}
+
+func TestPackageID(t *testing.T) {
+	p := Package{Name: "packages", Path: "golang.org/x/tools/go/packages", Module: "golang.org/x/tools", Version: "v0.1.0"}
+	if got, want := p.ID(), "golang.org/x/tools@v0.1.0/go/packages"; got != want {
+		t.Fatalf("Expecting ID to be %q, but got %q", want, got)
+	}
+
+	// main module and stdlib packages have no version
+	p = Package{Name: "foo", Path: "github.com/divan/depscheck/foo", Module: "github.com/divan/depscheck"}
+	if got, want := p.ID(), "github.com/divan/depscheck/foo"; got != want {
+		t.Fatalf("Expecting ID to be %q, but got %q", want, got)
+	}
+}
+
+func newPkg(path string, mod *packages.Module) *packages.Package {
+	return &packages.Package{
+		PkgPath: path,
+		Module:  mod,
+	}
+}
+
+func BenchmarkIsStdlibTrue(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		IsStdlib("fmt")
+	}
+}
+func BenchmarkIsStdlibFalse(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		IsStdlib("github.com/divan/package")
+	}
+}
+
+func BenchmarkIsInternal(b *testing.B) {
+	pkg := newPkg("github.com/divan/package1", &packages.Module{Path: "github.com/divan/package1", Main: true})
+	dep := newPkg("github.com/divan/package2", &packages.Module{Path: "github.com/divan/package2"})
+	for i := 0; i < b.N; i++ {
+		IsInternal(pkg, dep)
+	}
+}
@@ -0,0 +1,171 @@
+package analysis
+
+import (
+	"fmt"
+	"sort"
+)
+
+// PackageStat holds stats about dependencies in a given package.
+type PackageStat struct {
+	*Package
+
+	DepsCount      int
+	DepsCallsCount int
+
+	LOCCum               int
+	SLOCCum, StmtsCum    int
+	Depth, DepthInternal int
+
+	// LOCUnique is LOC of distinct functions, reachable
+	// from selectors of this package, and Complexity is
+	// their total and max complexity.
+	LOCUnique int
+	Complexity
+
+	// InitLOCCum is cumulative LOC of initialization of the package,
+	// run when it's imported, see Walker.WalkInit. Blank is true
+	// if it's imported with a blank import.
+	InitLOCCum int
+	Blank      bool
+}
+
+// NewPackageStat creates new PackageStat.
+func NewPackageStat(pkg Package) *PackageStat {
+	return &PackageStat{
+		Package: &pkg,
+	}
+}
+
+// String implements Stringer for PackageStat.
+func (p *PackageStat) String() string {
+	return fmt.Sprintf("%s: (%d, %d) [LOC: %d, %d unique] Depth [%d, %d]\n", p.Path, p.DepsCount, p.DepsCallsCount, p.LOCCum, p.LOCUnique, p.Depth, p.DepthInternal)
+}
+
+// PackagesStats returns stats by packages in all selectors.
+func (r *Result) PackagesStats() []*PackageStat {
+	pkgs := make(map[Package]*PackageStat)
+	selectors := make(map[Package]Deps)
+	counted := make(map[*Selector]bool)
+	var fields []*Selector
+	for _, sel := range r.All() {
+		selectors[sel.Pkg] = append(selectors[sel.Pkg], sel)
+		if _, ok := pkgs[sel.Pkg]; !ok {
+			pkgs[sel.Pkg] = NewPackageStat(sel.Pkg)
+		}
+		pkgs[sel.Pkg].DepsCallsCount += r.Counter[sel.ID()]
+		if sel.Type == "field" {
+			fields = append(fields, sel)
+			continue
+		}
+		pkgs[sel.Pkg].DepsCount++
+		pkgs[sel.Pkg].add(sel)
+		counted[sel] = true
+	}
+
+	// fields are not selectors of their own, but parts of their
+	// types, so every type is counted once, however many of its
+	// fields are used
+	for _, sel := range fields {
+		for _, owner := range sel.Deps {
+			if !counted[owner] {
+				pkgs[sel.Pkg].add(owner)
+				counted[owner] = true
+			}
+		}
+	}
+
+	// blank imported packages are used only for their initialization
+	for id, sel := range r.Inits {
+		stat, ok := pkgs[sel.Pkg]
+		if !ok && !r.Blank[id] {
+			continue
+		}
+		if !ok {
+			stat = NewPackageStat(sel.Pkg)
+			pkgs[sel.Pkg] = stat
+		}
+		stat.InitLOCCum = sel.LOCCum()
+		stat.Blank = r.Blank[id]
+	}
+
+	var ret []*PackageStat
+	for pkg, stat := range pkgs {
+		stat.LOCUnique = selectors[pkg].LOCUnique()
+		stat.Complexity = selectors[pkg].Complexity()
+		ret = append(ret, stat)
+	}
+	sort.Sort(ByPackageName(ret))
+	return ret
+}
+
+// add adds cumulative metrics of the selector to stat.
+func (p *PackageStat) add(sel *Selector) {
+	p.LOCCum += sel.LOCCum()
+	p.SLOCCum += sel.SLOCCum()
+	p.StmtsCum += sel.StmtsCum()
+	p.Depth += sel.Depth()
+	p.DepthInternal += sel.DepthInternal()
+}
+
+// Size returns cumulative size of the used code, measured
+// in the given metric, LOCCum by default.
+func (p *PackageStat) Size(metric string) int {
+	switch metric {
+	case MetricSLOC:
+		return p.SLOCCum
+	case MetricStmts:
+		return p.StmtsCum
+	}
+	return p.LOCCum
+}
+
+// CanBeAvoided attempts to classify if package usage is small enough
+// to suggest user to avoid this package as a dependency and
+// instead copy/embed it's code into own project (if license permits).
+//
+// Package usage is small if it doesn't exceed any of thresholds.
+// Packages, nothing of which is used, are imported for their
+// initialization only, so they can't be copied.
+func (p *PackageStat) CanBeAvoided(t Thresholds) bool {
+	if p.DepsCallsCount == 0 {
+		return false
+	}
+
+	if p.Depth > t.Depth {
+		return false
+	}
+	if p.DepthInternal > t.DepthInternal {
+		return false
+	}
+
+	if p.DepsCount > t.DepsCount {
+		return false
+	}
+
+	if p.Size(t.Metric) > t.LOCCum {
+		return false
+	}
+
+	if p.CyclomaticMax > t.Cyclomatic || p.CognitiveMax > t.Cognitive {
+		return false
+	}
+
+	if p.InitLOCCum > t.InitLOCCum {
+		return false
+	}
+
+	return true
+}
+
+// ByPackageName is a helper type for sorting PackageStats by Name,
+// and by Path for packages with the same name.
+type ByPackageName []*PackageStat
+
+func (b ByPackageName) Len() int      { return len(b) }
+func (b ByPackageName) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b ByPackageName) Less(i, j int) bool {
+	if b[i].Name != b[j].Name {
+		return b[i].Name < b[j].Name
+	}
+	return b[i].ID() < b[j].ID()
+}
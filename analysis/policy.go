@@ -0,0 +1,369 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Metrics of code size, which package size could be measured in.
+const (
+	MetricLOC   = "loc"   // lines of code, see Selector.LOCCum
+	MetricSLOC  = "sloc"  // lines of code without blank lines and comments, see Selector.SLOCCum
+	MetricStmts = "stmts" // statements, see Selector.StmtsCum
+)
+
+// MetricName returns human readable name of the metric, like "LOC".
+func MetricName(metric string) string {
+	switch metric {
+	case MetricSLOC:
+		return "SLOC"
+	case MetricStmts:
+		return "statements"
+	}
+	return "LOC"
+}
+
+// Thresholds holds limits for classifying package usage as small
+// enough to be removed from dependencies. See PackageStat.CanBeAvoided.
+//
+// LOCCum limits package size, measured in Metric (MetricLOC if empty).
+// Cyclomatic and Cognitive limit complexity of the most complex
+// function, reachable from the package. InitLOCCum limits cumulative
+// LOC of its initialization, see PackageStat.InitLOCCum.
+type Thresholds struct {
+	Depth         int    `json:"depth" yaml:"depth"`
+	DepthInternal int    `json:"depth_internal" yaml:"depth_internal"`
+	DepsCount     int    `json:"count" yaml:"count"`
+	LOCCum        int    `json:"loc_cum" yaml:"loc_cum"`
+	Cyclomatic    int    `json:"cyclomatic" yaml:"cyclomatic"`
+	Cognitive     int    `json:"cognitive" yaml:"cognitive"`
+	InitLOCCum    int    `json:"init_loc_cum" yaml:"init_loc_cum"`
+	Metric        string `json:"metric,omitempty" yaml:"metric"`
+}
+
+// DefaultThresholds are used unless configured otherwise.
+var DefaultThresholds = Thresholds{
+	// If this dependency is using another dependencies,
+	// it's almost for sure - no. For internal dependency, let's
+	// allow just two level of nesting.
+	Depth:         0,
+	DepthInternal: 2,
+
+	DepsCount: 3,
+
+	// Because 42
+	LOCCum: 42,
+	Metric: MetricLOC,
+
+	// Functions, simple enough to be copied without
+	// much thinking, as suggested by gocyclo and gocognit.
+	Cyclomatic: 10,
+	Cognitive:  15,
+
+	// Initialization runs whatever is used, and copied code
+	// can't do without it, so it's limited the same way.
+	InitLOCCum: 42,
+}
+
+// Validate returns error if thresholds, read from file, are invalid.
+func (t Thresholds) Validate() error {
+	if t.Metric == "" {
+		return nil
+	}
+	return checkMetric(t.Metric)
+}
+
+// checkMetric returns error if metric is unknown.
+func checkMetric(metric string) error {
+	switch metric {
+	case MetricLOC, MetricSLOC, MetricStmts:
+		return nil
+	}
+	return fmt.Errorf("unknown metric %q", metric)
+}
+
+// Set implements flag.Value, parsing thresholds in
+// "loc=42,count=3,depth=0,depthint=2,cyclo=10,cognit=15,init=42,metric=sloc" form.
+// Omitted values are left untouched.
+func (t *Thresholds) Set(s string) error {
+	for _, kv := range splitList(s) {
+		if metric, ok := strings.CutPrefix(kv, "metric="); ok {
+			if err := checkMetric(metric); err != nil {
+				return err
+			}
+			t.Metric = metric
+			continue
+		}
+
+		name, value, err := parseKV(kv)
+		if err != nil {
+			return err
+		}
+		switch name {
+		case "loc":
+			t.LOCCum = value
+		case "count":
+			t.DepsCount = value
+		case "depth":
+			t.Depth = value
+		case "depthint":
+			t.DepthInternal = value
+		case "cyclo":
+			t.Cyclomatic = value
+		case "cognit":
+			t.Cognitive = value
+		case "init":
+			t.InitLOCCum = value
+		default:
+			return fmt.Errorf("unknown threshold %q", name)
+		}
+	}
+	return nil
+}
+
+// String implements flag.Value and Stringer for Thresholds.
+func (t *Thresholds) String() string {
+	s := fmt.Sprintf("loc=%d,count=%d,depth=%d,depthint=%d,cyclo=%d,cognit=%d,init=%d",
+		t.LOCCum, t.DepsCount, t.Depth, t.DepthInternal, t.Cyclomatic, t.Cognitive, t.InitLOCCum)
+	if t.Metric != "" {
+		s += ",metric=" + t.Metric
+	}
+	return s
+}
+
+// Rule names, supported by policy.
+const (
+	RuleCandidate = "candidate" // package is a good candidate for removing
+	RuleLOC       = "loc"       // package LOCCum exceeds limit
+	RuleSLOC      = "sloc"      // package SLOCCum exceeds limit
+	RuleStmts     = "stmts"     // package StmtsCum exceeds limit
+	RuleCount     = "count"     // number of used selectors exceeds limit
+	RuleCalls     = "calls"     // number of calls exceeds limit
+	RuleDepth     = "depth"     // package Depth exceeds limit
+	RuleDepthInt  = "depthint"  // package DepthInternal exceeds limit
+	RuleCyclo     = "cyclo"     // cyclomatic complexity of any function exceeds limit
+	RuleCognit    = "cognit"    // cognitive complexity of any function exceeds limit
+	RuleInit      = "init"      // package InitLOCCum exceeds limit
+)
+
+// Rule is a single policy rule. Limit is ignored for RuleCandidate.
+type Rule struct {
+	Name  string
+	Limit int
+}
+
+// String implements Stringer for Rule.
+func (r Rule) String() string {
+	if r.Name == RuleCandidate {
+		return r.Name
+	}
+	return fmt.Sprintf("%s=%d", r.Name, r.Limit)
+}
+
+// ParseRule parses rule in "name" or "name=limit" form.
+func ParseRule(s string) (Rule, error) {
+	if s == RuleCandidate {
+		return Rule{Name: RuleCandidate}, nil
+	}
+	name, limit, err := parseKV(s)
+	if err != nil {
+		return Rule{}, err
+	}
+	switch name {
+	case RuleLOC, RuleSLOC, RuleStmts, RuleCount, RuleCalls, RuleDepth, RuleDepthInt, RuleCyclo, RuleCognit, RuleInit:
+	default:
+		return Rule{}, fmt.Errorf("unknown rule %q", name)
+	}
+	return Rule{Name: name, Limit: limit}, nil
+}
+
+// Rules is a list of policy rules. It implements flag.Value,
+// parsing comma separated list of rules, like "candidate,loc=500".
+type Rules []Rule
+
+// Set implements flag.Value for Rules.
+func (rs *Rules) Set(s string) error {
+	for _, item := range splitList(s) {
+		rule, err := ParseRule(item)
+		if err != nil {
+			return err
+		}
+		*rs = append(*rs, rule)
+	}
+	return nil
+}
+
+// String implements flag.Value and Stringer for Rules.
+func (rs *Rules) String() string {
+	var items []string
+	for _, r := range *rs {
+		items = append(items, r.String())
+	}
+	return strings.Join(items, ",")
+}
+
+// Policy describes conditions on which depscheck should fail,
+// which is useful for running it as a CI gate.
+type Policy struct {
+	Rules      Rules
+	Thresholds Thresholds
+}
+
+// Violation describes a rule, fired for the package.
+type Violation struct {
+	Rule    Rule
+	Package *PackageStat
+	Reason  string
+}
+
+// String implements Stringer for Violation.
+func (v Violation) String() string {
+	return fmt.Sprintf("%s (%s): rule %s: %s", v.Package.Name, v.Package.Path, v.Rule, v.Reason)
+}
+
+// Check checks result against policy rules and returns all violations,
+// sorted by package name and rule.
+//
+// Per-dependency overrides of result are respected: accepted packages
+// never violate policy. If result is compared to baseline, only
+// regressed packages are checked.
+func (p Policy) Check(r *Result) []Violation {
+	var ret []Violation
+	for _, stat := range r.PackagesStats() {
+		if r.Regressions != nil && !r.Regressions.Has(*stat.Package) {
+			continue
+		}
+		t, ok := ThresholdsFor(p.Thresholds, r.Overrides, *stat.Package)
+		if !ok {
+			continue
+		}
+		for _, rule := range p.Rules {
+			if reason, ok := check(rule, t, stat); ok {
+				ret = append(ret, Violation{
+					Rule:    rule,
+					Package: stat,
+					Reason:  reason,
+				})
+			}
+		}
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if ret[i].Package.Name != ret[j].Package.Name {
+			return ret[i].Package.Name < ret[j].Package.Name
+		}
+		if ret[i].Package.Path != ret[j].Package.Path {
+			return ret[i].Package.Path < ret[j].Package.Path
+		}
+		return ret[i].Rule.String() < ret[j].Rule.String()
+	})
+	return ret
+}
+
+func check(rule Rule, t Thresholds, stat *PackageStat) (string, bool) {
+	var value int
+	var what string
+	switch rule.Name {
+	case RuleCandidate:
+		if !stat.CanBeAvoided(t) {
+			return "", false
+		}
+		return fmt.Sprintf("good candidate for removing: %d %s (max %d), %d used (max %d), depth %d (max %d), depth int %d (max %d), complexity %d/%d (max %d/%d), init %d LOC (max %d)",
+			stat.Size(t.Metric), MetricName(t.Metric), t.LOCCum, stat.DepsCount, t.DepsCount, stat.Depth, t.Depth, stat.DepthInternal, t.DepthInternal,
+			stat.CyclomaticMax, stat.CognitiveMax, t.Cyclomatic, t.Cognitive, stat.InitLOCCum, t.InitLOCCum), true
+	case RuleLOC:
+		value, what = stat.LOCCum, "cumulative LOC"
+	case RuleSLOC:
+		value, what = stat.SLOCCum, "cumulative SLOC"
+	case RuleStmts:
+		value, what = stat.StmtsCum, "cumulative statements"
+	case RuleCount:
+		value, what = stat.DepsCount, "used selectors"
+	case RuleCalls:
+		value, what = stat.DepsCallsCount, "calls"
+	case RuleDepth:
+		value, what = stat.Depth, "depth"
+	case RuleDepthInt:
+		value, what = stat.DepthInternal, "depth int"
+	case RuleCyclo:
+		value, what = stat.CyclomaticMax, "cyclomatic complexity"
+	case RuleCognit:
+		value, what = stat.CognitiveMax, "cognitive complexity"
+	case RuleInit:
+		value, what = stat.InitLOCCum, "cumulative LOC of initialization"
+	}
+	if value <= rule.Limit {
+		return "", false
+	}
+	return fmt.Sprintf("%d %s exceeds limit of %d", value, what, rule.Limit), true
+}
+
+// policyConfig represents policy config file in JSON format.
+type policyConfig struct {
+	FailOn     []string    `json:"fail_on"`
+	Thresholds *Thresholds `json:"thresholds"`
+}
+
+// LoadPolicy reads policy from JSON file, like:
+//
+//	{
+//	    "fail_on": ["candidate", "loc=500"],
+//	    "thresholds": {"loc_cum": 60, "count": 3, "depth": 0, "depth_internal": 2, "cyclomatic": 10, "metric": "sloc"}
+//	}
+//
+// File is layered on top of base policy: thresholds missing in file are
+// taken from base, and rules replace base rules only if file lists any.
+func LoadPolicy(filename string, base Policy) (Policy, error) {
+	policy := base
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return policy, err
+	}
+
+	file := policyConfig{Thresholds: &policy.Thresholds}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return policy, fmt.Errorf("%s: %v", filename, err)
+	}
+	if err := policy.Thresholds.Validate(); err != nil {
+		return policy, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	if len(file.FailOn) > 0 {
+		policy.Rules = nil
+	}
+	for _, s := range file.FailOn {
+		rule, err := ParseRule(s)
+		if err != nil {
+			return policy, fmt.Errorf("%s: %v", filename, err)
+		}
+		policy.Rules = append(policy.Rules, rule)
+	}
+
+	return policy, nil
+}
+
+func splitList(s string) []string {
+	var ret []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+func parseKV(s string) (string, int, error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("expected name=value, got %q", s)
+	}
+	value, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid value for %s: %v", parts[0], err)
+	}
+	return parts[0], value, nil
+}
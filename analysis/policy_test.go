@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicy(t *testing.T) {
+	src := "test/exported.go"
+	result := getResult(t, true, src)
+
+	policy := Policy{Thresholds: DefaultThresholds}
+	if err := policy.Rules.Set("candidate,loc=10"); err != nil {
+		t.Fatal(err)
+	}
+
+	violations := policy.Check(result)
+	if len(violations) != 2 {
+		t.Fatalf("%s: expected to have 2 violations, but have %d: %v", src, len(violations), violations)
+	}
+	if rule := violations[0].Rule; rule.Name != RuleCandidate {
+		t.Fatalf("%s: expected first violation to be %s, but got %s", src, RuleCandidate, rule)
+	}
+	if rule := violations[1].Rule; rule.Name != RuleLOC || rule.Limit != 10 {
+		t.Fatalf("%s: expected second violation to be loc=10, but got %s", src, rule)
+	}
+
+	// xsample uses 14 LOC, so it's not a candidate anymore
+	if err := policy.Thresholds.Set("loc=13"); err != nil {
+		t.Fatal(err)
+	}
+	if violations = policy.Check(result); len(violations) != 1 {
+		t.Fatalf("%s: expected to have 1 violation, but have %d: %v", src, len(violations), violations)
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	var rules Rules
+	for _, s := range []string{"foo", "loc", "loc=x", "candidate=1"} {
+		if err := rules.Set(s); err == nil {
+			t.Fatalf("Expecting rule %q to be invalid", s)
+		}
+	}
+
+	var th Thresholds
+	if err := th.Set("loc=1,count=2,depth=3,depthint=4,cyclo=5,cognit=6,init=7,metric=sloc"); err != nil {
+		t.Fatal(err)
+	}
+	if want := (Thresholds{LOCCum: 1, DepsCount: 2, Depth: 3, DepthInternal: 4, Cyclomatic: 5, Cognitive: 6, InitLOCCum: 7, Metric: MetricSLOC}); th != want {
+		t.Fatalf("Expecting thresholds to be %v, but got %v", want, th)
+	}
+	if err := th.Set("metric=words"); err == nil {
+		t.Fatalf("Expecting metric words to be invalid")
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "policy.json")
+	data := `{"fail_on": ["candidate", "depth=2"], "thresholds": {"loc_cum": 100}}`
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	base := Policy{Thresholds: DefaultThresholds}
+	base.Thresholds.DepsCount = 10
+	if err := base.Rules.Set("loc=500"); err != nil {
+		t.Fatal(err)
+	}
+
+	policy, err := LoadPolicy(filename, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := policy.Rules.String(); got != "candidate,depth=2" {
+		t.Fatalf("Expecting rules to be candidate,depth=2, but got %s", got)
+	}
+	want := base.Thresholds
+	want.LOCCum = 100
+	if policy.Thresholds != want {
+		t.Fatalf("Expecting thresholds to be %v, but got %v", want, policy.Thresholds)
+	}
+
+	// rules of base are kept, if file has none
+	data = `{"thresholds": {"depth": 1}}`
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err = LoadPolicy(filename, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := policy.Rules.String(); got != "loc=500" {
+		t.Fatalf("Expecting rules to be loc=500, but got %s", got)
+	}
+	want = base.Thresholds
+	want.Depth = 1
+	if policy.Thresholds != want {
+		t.Fatalf("Expecting thresholds to be %v, but got %v", want, policy.Thresholds)
+	}
+
+	data = `{"thresholds": {"metric": "slco"}}`
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = LoadPolicy(filename, base); err == nil {
+		t.Fatalf("Expecting policy with unknown metric to fail")
+	}
+}
@@ -0,0 +1,183 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Result holds final result of this tool.
+type Result struct {
+	Selectors map[string]*Selector
+	Counter   map[string]int
+
+	// Inits holds initialization of dependencies, imported by
+	// our packages, keyed by Package.ID, see Walker.WalkInit.
+	// Blank holds those of them, imported with blank imports.
+	Inits map[string]*Selector
+	Blank map[string]bool
+
+	// Thresholds used for suggestions and
+	// their per-dependency overrides
+	Thresholds Thresholds
+	Overrides  []Override
+
+	// Regressions, if set, limits suggestions and policy
+	// checks to packages regressed since baseline.
+	Regressions *Regressions
+}
+
+// NewResult inits new Result.
+func NewResult() *Result {
+	return &Result{
+		Selectors: make(map[string]*Selector),
+		Counter:   make(map[string]int),
+		Inits:     make(map[string]*Selector),
+		Blank:     make(map[string]bool),
+
+		Thresholds: DefaultThresholds,
+	}
+}
+
+// Add adds new selector to the result.
+func (r *Result) Add(sel *Selector) {
+	key := sel.ID()
+	if _, ok := r.Selectors[key]; !ok {
+		r.Selectors[key] = sel
+	}
+	r.Counter[key]++
+}
+
+// Merge adds all selectors of other result to r, summing
+// counters of selectors found in both.
+func (r *Result) Merge(other *Result) {
+	for key, sel := range other.Selectors {
+		if _, ok := r.Selectors[key]; !ok {
+			r.Selectors[key] = sel
+		}
+		r.Counter[key] += other.Counter[key]
+	}
+	for id, sel := range other.Inits {
+		r.Inits[id] = sel
+	}
+	for id := range other.Blank {
+		r.Blank[id] = true
+	}
+}
+
+// Remove removes all selectors matching ignore list from the result.
+func (r *Result) Remove(ignore IgnoreList) {
+	for key, sel := range r.Selectors {
+		if ignore.Ignores(sel) {
+			delete(r.Selectors, key)
+			delete(r.Counter, key)
+		}
+	}
+	for id, sel := range r.Inits {
+		if ignore.Ignores(sel) {
+			delete(r.Inits, id)
+			delete(r.Blank, id)
+		}
+	}
+}
+
+// All returns all known selectors in result.
+func (r *Result) All() []*Selector {
+	var ret []*Selector
+	for _, sel := range r.Selectors {
+		ret = append(ret, sel)
+	}
+	return ret
+}
+
+// Cycles returns all cycles (mutually recursive functions) among
+// selectors and their dependencies. Selectors of each cycle are
+// sorted by ID, and cycles are sorted by ID of the first one.
+func (r *Result) Cycles() [][]*Selector {
+	var ret [][]*Selector
+	seen := make(map[*Selector]bool)
+	var walk func(deps Deps)
+	walk = func(deps Deps) {
+		for _, sel := range deps {
+			if seen[sel] {
+				continue
+			}
+			seen[sel] = true
+			if !sel.IsFunc() {
+				continue
+			}
+			if cycle := sel.Cycle(); cycle != nil && cycle[0] == sel {
+				ret = append(ret, cycle)
+			}
+			walk(sel.Deps)
+		}
+	}
+	walk(r.All())
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i][0].ID() < ret[j][0].ID()
+	})
+	return ret
+}
+
+// PrintDeps recursively prints deps for all selectors found to w.
+func (r *Result) PrintDeps(w io.Writer) {
+	for _, s := range r.All() {
+		s.PrintDeps(w)
+	}
+}
+
+// Suggestions analyzes results and print suggestions on deps.
+//
+// Candidates returns stats for packages that could be
+// removed from dependencies.
+func (r *Result) Candidates() []*PackageStat {
+	var ret []*PackageStat
+	for _, p := range r.PackagesStats() {
+		if r.Regressions != nil && !r.Regressions.Has(*p.Package) {
+			continue
+		}
+		t, ok := ThresholdsFor(r.Thresholds, r.Overrides, *p.Package)
+		if ok && p.CanBeAvoided(t) {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
+
+// Totals represnts total stats for all packages.
+type Totals struct {
+	Package string
+
+	Packages      int
+	LOC           int
+	LOCUnique     int
+	Calls         int
+	Depth         int
+	DepthInternal int
+	InitLOC       int
+}
+
+// Totals computes Totals for Result.
+func (r *Result) Totals(pkg string) *Totals {
+	t := &Totals{
+		Package: pkg,
+	}
+	for _, stat := range r.PackagesStats() {
+		t.Packages++
+		t.LOC += stat.LOCCum
+		t.Calls += stat.DepsCallsCount
+		t.Depth += stat.Depth
+		t.DepthInternal += stat.DepthInternal
+		t.InitLOC += stat.InitLOCCum
+	}
+	// packages may share dependencies, so it's not a sum
+	t.LOCUnique = Deps(r.All()).LOCUnique()
+	return t
+}
+
+// String implements Stringer for Totals type.
+func (t Totals) String() string {
+	return fmt.Sprintf("%s: %d packages, %d LOC, %d calls, %d depth, %d depth int, %d unique LOC, %d LOC of initialization.",
+		t.Package, t.Packages, t.LOC, t.Calls, t.Depth, t.DepthInternal, t.LOCUnique, t.InitLOC)
+}
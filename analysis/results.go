@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"sort"
+)
+
+// Results holds Result for each of our (initial) packages and
+// aggregate Result for all of them, with shared selectors
+// counted once.
+type Results struct {
+	// Packages holds import paths of our packages, sorted.
+	Packages []string
+	Results  map[string]*Result
+
+	Aggregate *Result
+}
+
+// NewResults inits new Results.
+func NewResults() *Results {
+	return &Results{
+		Results:   make(map[string]*Result),
+		Aggregate: NewResult(),
+	}
+}
+
+// Result returns Result for our package with given import path,
+// creating it if needed.
+func (rs *Results) Result(path string) *Result {
+	if r, ok := rs.Results[path]; ok {
+		return r
+	}
+	r := NewResult()
+	rs.Results[path] = r
+	rs.Packages = append(rs.Packages, path)
+	sort.Strings(rs.Packages)
+	return r
+}
+
+// Each calls fn for the aggregate and every per-package Result,
+// which is handy for applying settings to all of them.
+func (rs *Results) Each(fn func(r *Result)) {
+	fn(rs.Aggregate)
+	for _, path := range rs.Packages {
+		fn(rs.Results[path])
+	}
+}
+
+// UsedBy returns import paths of our packages, using each
+// dependency, keyed by dependency ID (see Package.ID).
+func (rs *Results) UsedBy() map[string][]string {
+	ret := make(map[string][]string)
+	for _, path := range rs.Packages {
+		seen := make(map[string]bool)
+		for _, sel := range rs.Results[path].Selectors {
+			id := sel.Pkg.ID()
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			ret[id] = append(ret[id], path)
+		}
+		// blank imported packages have no selectors
+		for id := range rs.Results[path].Blank {
+			if !seen[id] {
+				seen[id] = true
+				ret[id] = append(ret[id], path)
+			}
+		}
+	}
+	// rs.Packages is sorted, so are the values
+	return ret
+}
@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+const (
+	multiAPkg = "github.com/divan/depscheck/test/multi/a"
+	multiBPkg = "github.com/divan/depscheck/test/multi/b"
+)
+
+func TestWalkAll(t *testing.T) {
+	pattern := "./multi/..."
+	results := analyze(t, Options{Internal: true}, pattern)
+
+	if len(results.Packages) != 2 || results.Packages[0] != multiAPkg || results.Packages[1] != multiBPkg {
+		t.Fatalf("%s: unexpected packages: %v", pattern, results.Packages)
+	}
+
+	a, b := results.Results[multiAPkg], results.Results[multiBPkg]
+	checkCount(multiAPkg, t, a, 2)
+	checkSelector(multiAPkg, t, a, samplePkg+".func.SampleFunc", 1, 6, 14, 0, 2)
+	checkSelector(multiAPkg, t, a, fooPkg+".func.Foo", 1, 4, 12, 1, 0)
+	checkCount(multiBPkg, t, b, 1)
+	checkSelector(multiBPkg, t, b, samplePkg+".func.SampleFunc", 1, 6, 14, 0, 2)
+
+	// shared selector is counted once, with calls summed
+	checkCount(pattern, t, results.Aggregate, 2)
+	checkSelector(pattern, t, results.Aggregate, samplePkg+".func.SampleFunc", 2, 6, 14, 0, 2)
+	if totals := results.Aggregate.Totals(pattern); totals.LOC != 26 || totals.Calls != 3 {
+		t.Fatalf("%s: unexpected aggregate totals: %v", pattern, totals)
+	}
+
+	usedBy := results.UsedBy()
+	if users := usedBy[samplePkg]; len(users) != 2 || users[0] != multiAPkg || users[1] != multiBPkg {
+		t.Fatalf("%s: expected sample to be used by both packages, but got %v", pattern, users)
+	}
+	if users := usedBy[fooPkg]; len(users) != 1 || users[0] != multiAPkg {
+		t.Fatalf("%s: expected foo to be used by %s only, but got %v", pattern, multiAPkg, users)
+	}
+
+	report := results.JSON(pattern, true)
+	if len(report.Analyzed) != 2 || report.Analyzed[1].Path != multiBPkg || report.Analyzed[1].Totals.LOC != 14 {
+		t.Fatalf("%s: unexpected analyzed packages in JSON: %+v", pattern, report.Analyzed)
+	}
+	for _, p := range report.Packages {
+		if p.Path == samplePkg && len(p.UsedBy) != 2 {
+			t.Fatalf("%s: expected sample to be used by 2 packages in JSON, but got %v", pattern, p.UsedBy)
+		}
+	}
+}
+
+func TestWalkAllJobs(t *testing.T) {
+	patterns := []string{"./multi/...", "./lib/...", "./sample", "./foo", "./bar", "./diamond", "./shape"}
+	for _, backend := range []string{BackendAST, BackendSSA} {
+		var want []byte
+		for _, jobs := range []int{1, 2, 8} {
+			results := analyze(t, Options{Internal: true, Backend: backend, Jobs: jobs}, patterns...)
+			got, err := json.Marshal(results.JSON("test", true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want == nil {
+				want = got
+				continue
+			}
+			if !bytes.Equal(got, want) {
+				t.Fatalf("%s: results with %d jobs differ from sequential ones:\n%s\n%s", backend, jobs, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package analysis
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Selector represents Go language selector (x.f),
+// which may be:
+// - method of variable of external package
+// - function from the external package
+// - variable/const from ext. package
+type Selector struct {
+	Pkg  Package
+	Name string
+	Type string
+	Recv string
+
+	LOC  int // actual Lines Of Code
+	SLOC int // Source Lines Of Code, without blank lines and comments
+
+	// Applies for functions
+	Stmts      int // number of statements
+	Cyclomatic int // cyclomatic complexity
+	Cognitive  int // cognitive complexity
+
+	Deps Deps
+
+	// component of the dependency graph, see component()
+	comp *component
+}
+
+// String implements Stringer interface for Selector.
+func (s *Selector) String() string {
+	var out string
+	if s.Recv != "" {
+		out = fmt.Sprintf("%s.(%s).%s.%s", s.Pkg.Name, s.Recv, s.Type, s.Name)
+	}
+	out = fmt.Sprintf("%s.%s.%s", s.Pkg.Name, s.Type, s.Name)
+
+	if s.Type == "func" || s.Type == "method" {
+		out = fmt.Sprintf("%s LOC: %d, %d, Depth: %d,%d", out, s.LOC, s.LOCCum(), s.Depth(), s.DepthInternal())
+	}
+
+	return out
+}
+
+// ID generates uniqie string ID for this selector.
+//
+// ID is qualified with package path and module version (see Package.ID),
+// so packages with the same name never collide.
+func (s *Selector) ID() string {
+	if s.Recv != "" {
+		return fmt.Sprintf("%s.(%s).%s.%s", s.Pkg.ID(), s.Recv, s.Type, s.Name)
+	}
+	return fmt.Sprintf("%s.%s.%s", s.Pkg.ID(), s.Type, s.Name)
+}
+
+// pathID is like ID, but qualified with package path only, so
+// it's stable across module versions. Used to match selectors
+// of different Results (see Baseline and Diff).
+func (s *Selector) pathID() string {
+	if s.Recv != "" {
+		return fmt.Sprintf("%s.(%s).%s.%s", s.Pkg.Path, s.Recv, s.Type, s.Name)
+	}
+	return fmt.Sprintf("%s.%s.%s", s.Pkg.Path, s.Type, s.Name)
+}
+
+// NewSelector creates new Selector.
+func NewSelector(pkg Package, name, recv, typ string, loc int) *Selector {
+	return &Selector{
+		Pkg:  pkg,
+		Name: name,
+
+		Recv: recv,
+		Type: typ,
+
+		LOC: loc,
+	}
+}
+
+// setSize sets metrics of the function, other than LOC.
+func (s *Selector) setSize(size FuncSize) {
+	s.SLOC = size.SLOC
+	s.Stmts = size.Stmts
+	s.Cyclomatic = size.Cyclomatic
+	s.Cognitive = size.Cognitive
+}
+
+// LOCCum returns cumulative LOC count for Selector and all it's dependencies,
+// including types, consts and vars they use.
+//
+// Selectors in a cycle (mutually recursive functions or types) are
+// counted once, sharing the same LOCCum.
+func (s *Selector) LOCCum() int {
+	return s.component().locCum
+}
+
+// SLOCCum returns cumulative SLOC count for Selector and all it's
+// dependencies, counted the same way as LOCCum.
+func (s *Selector) SLOCCum() int {
+	return s.component().slocCum
+}
+
+// StmtsCum returns cumulative number of statements for Selector
+// and all it's dependencies, counted the same way as LOCCum.
+func (s *Selector) StmtsCum() int {
+	return s.component().stmtsCum
+}
+
+// LOCUnique returns LOC of Selector and all selectors reachable
+// from it, counting every one once. Unlike LOCCum, it doesn't
+// grow when the same helper is called from many places.
+func (s *Selector) LOCUnique() int {
+	return Deps{s}.LOCUnique()
+}
+
+// Depth returns Depth for Selector and all it's external dependencies.
+func (s *Selector) Depth() int {
+	if !s.IsFunc() {
+		return 0
+	}
+	return s.component().depth
+}
+
+// DepthInternal returns Depth for Selector and all it's internal dependencies.
+func (s *Selector) DepthInternal() int {
+	if !s.IsFunc() {
+		return 0
+	}
+	return s.component().depthInternal
+}
+
+// IsFunc returns true if Selector is either a function or a method.
+func (s *Selector) IsFunc() bool {
+	return s.Type == "func" || s.Type == "method"
+}
+
+// PrintDeps recursively prints deps for selector to w.
+//
+// Cycles are printed once, marked with "(cycle)".
+func (s *Selector) PrintDeps(w io.Writer) {
+	s.printDeps(w, 0, make(map[*Selector]bool))
+}
+
+func (s *Selector) printDeps(w io.Writer, depth int, path map[*Selector]bool) {
+	if path[s] {
+		fmt.Fprintln(w, strings.Repeat("  ", depth), fmt.Sprintf("%s.%s (cycle)", s.Pkg.Name, s.Name))
+		return
+	}
+	fmt.Fprintln(w, strings.Repeat("  ", depth), fmt.Sprintf("%s.%s", s.Pkg.Name, s.Name))
+
+	path[s] = true
+	for _, dep := range s.Deps {
+		dep.printDeps(w, depth+1, path)
+	}
+	delete(path, s)
+}
+
+// ByID is helper type for sorting selectors by ID.
+type ByID []*Selector
+
+func (b ByID) Len() int      { return len(b) }
+func (b ByID) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b ByID) Less(i, j int) bool {
+	return b[i].ID() < b[j].ID()
+}
+
+// Deps is a shorthand for Dependencies - a slice of Selectors.
+type Deps []*Selector
+
+// Append adds new Selector to Deps.
+func (deps *Deps) Append(s *Selector) {
+	for _, d := range *deps {
+		if d.ID() == s.ID() {
+			return
+		}
+	}
+	*deps = append(*deps, s)
+}
+
+// LOCUnique returns LOC of all distinct selectors in deps
+// and their dependencies.
+func (deps Deps) LOCUnique() int {
+	var ret int
+	deps.each(func(s *Selector) {
+		ret += s.LOC
+	})
+	return ret
+}
+
+// Complexity holds sum and max of complexity metrics of functions.
+type Complexity struct {
+	Cyclomatic, CyclomaticMax int
+	Cognitive, CognitiveMax   int
+}
+
+// Complexity returns complexity of all distinct functions in deps
+// and their dependencies.
+func (deps Deps) Complexity() Complexity {
+	var ret Complexity
+	deps.each(func(s *Selector) {
+		ret.Cyclomatic += s.Cyclomatic
+		ret.CyclomaticMax = max(ret.CyclomaticMax, s.Cyclomatic)
+		ret.Cognitive += s.Cognitive
+		ret.CognitiveMax = max(ret.CognitiveMax, s.Cognitive)
+	})
+	return ret
+}
+
+// each calls fn for every distinct selector in deps
+// and their dependencies.
+func (deps Deps) each(fn func(s *Selector)) {
+	seen := make(map[string]bool)
+	var walk func(deps Deps)
+	walk = func(deps Deps) {
+		for _, dep := range deps {
+			id := dep.ID()
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			fn(dep)
+			walk(dep.Deps)
+		}
+	}
+	walk(deps)
+}
@@ -0,0 +1,260 @@
+package analysis
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"sync"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/callgraph/rta"
+	"golang.org/x/tools/go/callgraph/vta"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+// Call graph construction algorithms, supported by SSA backend.
+const (
+	CallGraphCHA = "cha" // Class Hierarchy Analysis, sound and fast, but imprecise
+	CallGraphRTA = "rta" // Rapid Type Analysis, only for types reachable from our code
+	CallGraphVTA = "vta" // Variable Type Analysis, the most precise one
+)
+
+// SSA is an alternative analysis backend for Walker, based on
+// go/ssa and call graph. Unlike AST walking, it sees calls through
+// interfaces, method values, function variables and closures, so
+// LOCCum, Depth and DepthInternal reflect code that dependency
+// can actually execute.
+//
+// Only funcs and methods are handled by SSA, other selectors
+// are walked as usual.
+type SSA struct {
+	Prog  *ssa.Program
+	Graph *callgraph.Graph
+
+	// our packages
+	initial map[*types.Package]bool
+
+	mu      sync.Mutex // guards visited and methods
+	visited map[*ssa.Function]*Selector
+	methods map[*types.Func]*Selector // interface methods
+
+	// callees of interface methods, called from our code
+	sitesOnce sync.Once
+	sites     map[*types.Func][]*ssa.Function
+}
+
+// NewSSA builds SSA program and call graph for packages
+// of the walker, using given algorithm.
+func NewSSA(w *Walker, algo string) (*SSA, error) {
+	prog, pkgs := ssautil.AllPackages(w.Initial, ssa.InstantiateGenerics)
+	prog.Build()
+
+	s := &SSA{
+		Prog:    prog,
+		initial: make(map[*types.Package]bool),
+		visited: make(map[*ssa.Function]*Selector),
+		methods: make(map[*types.Func]*Selector),
+	}
+	for _, pkg := range w.Initial {
+		s.initial[pkg.Types] = true
+	}
+
+	switch algo {
+	case CallGraphCHA:
+		s.Graph = cha.CallGraph(prog)
+	case CallGraphRTA:
+		// Our packages may be libraries without main, so every
+		// function of them is considered to be reachable.
+		var roots []*ssa.Function
+		for fn := range ssautil.AllFunctions(prog) {
+			if fn.Pkg != nil && s.initial[fn.Pkg.Pkg] {
+				roots = append(roots, fn)
+			}
+		}
+		for _, pkg := range pkgs {
+			if pkg != nil {
+				if init := pkg.Func("init"); init != nil {
+					roots = append(roots, init)
+				}
+			}
+		}
+		s.Graph = rta.Analyze(roots, true).CallGraph
+	case CallGraphVTA:
+		s.Graph = vta.CallGraph(ssautil.AllFunctions(prog), cha.CallGraph(prog))
+	default:
+		return nil, fmt.Errorf("unknown call graph algorithm: %s", algo)
+	}
+
+	return s, nil
+}
+
+// WalkFunc builds Selector for func or method object, with all
+// functions it may call as Deps.
+func (s *SSA) WalkFunc(w *Walker, pkg Package, obj *types.Func) *Selector {
+	if fn := s.Prog.FuncValue(obj); fn != nil {
+		return s.walkFunction(w, fn)
+	}
+
+	// Interface method: depends on all implementations,
+	// called from our code.
+	s.mu.Lock()
+	sel, ok := s.methods[obj]
+	s.mu.Unlock()
+	if ok {
+		return sel
+	}
+
+	var deps Deps
+	for _, fn := range s.implementations(obj) {
+		for _, dep := range s.resolve(w, fn, nil) {
+			deps.Append(dep)
+		}
+	}
+	sort.Sort(ByID(deps))
+
+	_, recv, _ := ObjectKind(obj)
+	sel = NewSelector(pkg, obj.Name(), recv, "method", 0)
+	sel.Deps = deps
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prev, ok := s.methods[obj]; ok {
+		return prev
+	}
+	s.methods[obj] = sel
+	return sel
+}
+
+// implementations returns functions, called from our code
+// through the interface method.
+//
+// Call graph is scanned once for all interface methods.
+func (s *SSA) implementations(method *types.Func) []*ssa.Function {
+	s.sitesOnce.Do(func() {
+		s.sites = make(map[*types.Func][]*ssa.Function)
+		for fn, node := range s.Graph.Nodes {
+			if fn == nil || fn.Pkg == nil || !s.initial[fn.Pkg.Pkg] {
+				continue
+			}
+			for _, edge := range node.Out {
+				if edge.Site == nil || edge.Site.Common().Method == nil {
+					continue
+				}
+				m := edge.Site.Common().Method
+				s.sites[m] = append(s.sites[m], edge.Callee.Func)
+			}
+		}
+	})
+	return s.sites[method]
+}
+
+// walkFunction builds Selector for the SSA function.
+//
+// It returns nil for functions that should be skipped (stdlib
+// in non-stdlib mode, synthetic functions without syntax).
+func (s *SSA) walkFunction(w *Walker, fn *ssa.Function) *Selector {
+	s.mu.Lock()
+	sel, ok := s.visited[fn]
+	s.mu.Unlock()
+	if ok {
+		return sel
+	}
+
+	origin := fn
+	if fn.Origin() != nil {
+		origin = fn.Origin()
+	}
+	obj, ok := origin.Object().(*types.Func)
+	if !ok || obj.Pkg() == nil {
+		return nil
+	}
+	if !w.Stdlib && IsStdlib(obj.Pkg().Path()) {
+		return nil
+	}
+	pkg := w.Package(obj.Pkg().Path())
+	if pkg == nil {
+		return nil
+	}
+
+	typ, recv, _ := ObjectKind(obj)
+	var size FuncSize
+	decl, _ := origin.Syntax().(*ast.FuncDecl)
+	if decl != nil {
+		size = w.Size(decl)
+	}
+
+	// see Walker.WalkObject on publishing selector before walking
+	s.mu.Lock()
+	if sel, ok := s.visited[fn]; ok {
+		s.mu.Unlock()
+		return sel
+	}
+	sel = NewSelector(PackageOf(pkg), obj.Name(), recv, typ, size.LOC)
+	sel.setSize(size)
+	s.visited[fn] = sel
+	s.mu.Unlock()
+
+	var deps Deps
+	for _, dep := range s.callees(w, fn, nil) {
+		deps.Append(dep)
+	}
+	// call graph knows nothing about types, consts, vars and
+	// fields, and funcs used as values, but not called here
+	if decl != nil {
+		objs := append(w.funcs(pkg, decl, false), w.refs(pkg, decl)...)
+		for _, dep := range w.walkObjects(append(objs, w.fields(pkg, decl)...)) {
+			deps.Append(dep)
+		}
+	}
+	// call graph nodes have no stable order
+	sort.Sort(ByID(deps))
+
+	sel.Deps = deps
+
+	return sel
+}
+
+// callees returns Selectors for all functions called by fn.
+func (s *SSA) callees(w *Walker, fn *ssa.Function, seen map[*ssa.Function]bool) []*Selector {
+	if seen == nil {
+		seen = make(map[*ssa.Function]bool)
+	}
+	if seen[fn] {
+		return nil
+	}
+	seen[fn] = true
+
+	var ret []*Selector
+	if node := s.Graph.Nodes[fn]; node != nil {
+		for _, edge := range node.Out {
+			if edge.Callee.Func == fn {
+				continue
+			}
+			ret = append(ret, s.resolve(w, edge.Callee.Func, seen)...)
+		}
+	}
+
+	// closures may be not called directly, but still belong to fn
+	for _, anon := range fn.AnonFuncs {
+		ret = append(ret, s.callees(w, anon, seen)...)
+	}
+
+	return ret
+}
+
+// resolve returns Selectors for the called function.
+//
+// Closures and synthetic wrappers are transparent: functions
+// called by them are treated as called by the caller itself.
+func (s *SSA) resolve(w *Walker, fn *ssa.Function, seen map[*ssa.Function]bool) []*Selector {
+	if fn.Parent() != nil || (fn.Synthetic != "" && fn.Origin() == nil) {
+		return s.callees(w, fn, seen)
+	}
+	if sel := s.walkFunction(w, fn); sel != nil {
+		return []*Selector{sel}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package analysis
+
+import (
+	"testing"
+)
+
+const (
+	shapePkg = "github.com/divan/depscheck/test/shape"
+	pingPkg  = "github.com/divan/depscheck/test/ping"
+	pongPkg  = "github.com/divan/depscheck/test/pong"
+)
+
+func TestSSA(t *testing.T) {
+	var result *Result
+	var src string
+
+	for _, algo := range []string{CallGraphCHA, CallGraphRTA, CallGraphVTA} {
+		src = "test/exported.go"
+		result = getSSAResult(t, algo, src)
+		checkCount(src, t, result, 2)
+		checkSelector(src, t, result, samplePkg+".var.Sample", 1, 1, 1, 0, 0)
+		checkSelector(src, t, result, samplePkg+".func.SampleFunc", 1, 6, 14, 0, 2)
+
+		src = "test/recursion.go"
+		result = getSSAResult(t, algo, src)
+		checkCount(src, t, result, 2)
+		checkSelector(src, t, result, barPkg+".func.Bar", 1, 4, 8, 0, 1)
+		checkSelector(src, t, result, fooPkg+".func.Foo", 1, 4, 12, 1, 0)
+
+		// Calls through interfaces and function variables are
+		// invisible to AST walker.
+		src = "test/indirect.go"
+		result = getSSAResult(t, algo, src)
+		checkCount(src, t, result, 4)
+		checkSelector(src, t, result, shapePkg+".func.NewSquare", 1, 2, 5, 0, 0)
+		checkSelector(src, t, result, shapePkg+".(Shape).method.Area", 1, 0, 7, 0, 1)
+		checkSelector(src, t, result, shapePkg+".func.Describe", 1, 3, 19, 0, 2)
+		checkSelector(src, t, result, shapePkg+".interface.Shape", 1, 3, 3, 0, 0)
+
+		// ping.Ping.Pong and pong.Serve call each other through
+		// pong.Ponger, which only SSA backend sees.
+		src = "test/pingpong.go"
+		result = getSSAResult(t, algo, src)
+		checkSelector(src, t, result, pingPkg+".(Ping).method.Pong", 1, 2, 11, 1, 0)
+		if cycle := result.Selectors[pingPkg+".(Ping).method.Pong"].Cycle(); len(cycle) != 2 || cycle[1].ID() != pongPkg+".func.Serve" {
+			t.Fatalf("%s: expected Ping.Pong to be in cycle with pong.Serve, but got %v", src, cycle)
+		}
+	}
+
+	src = "test/indirect.go"
+	result = getResult(t, true, src)
+	checkSelector(src, t, result, shapePkg+".(Shape).method.Area", 1, 0, 0, 0, 0)
+}
+
+func getSSAResult(t *testing.T, algo string, sources ...string) *Result {
+	opts := Options{
+		Internal:  true,
+		Backend:   BackendSSA,
+		CallGraph: algo,
+	}
+	return analyze(t, opts, relSources(t, sources)...).Aggregate
+}
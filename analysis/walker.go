@@ -0,0 +1,918 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Walker holds all information needed during walking
+// and analyzing AST source tree.
+//
+// Walker is safe for concurrent use: caches are guarded by a mutex,
+// and every declaration is walked only once, by the goroutine which
+// visited it first. Others get the same Selector, which may have
+// its Deps not walked yet, so selectors metrics should be used only
+// after walking is done.
+type Walker struct {
+	Fset     *token.FileSet
+	Initial  []*packages.Package
+	Packages map[string]Package
+
+	// CacheSize holds size metrics of walked functions
+	CacheSize map[*ast.FuncDecl]FuncSize
+
+	Options
+
+	// SSA backend, if set, is used for funcs and methods
+	// instead of walking AST.
+	SSA *SSA
+
+	// Visited holds walked selectors by FuncDecl for funcs and
+	// methods, and by declaring Ident for others.
+	Visited map[ast.Node]*Selector
+
+	// mu guards CacheSize, Visited, indices and noInit
+	mu sync.Mutex
+
+	// all loaded packages (initial and deps) by import path
+	all map[string]*packages.Package
+
+	// lookup indices of packages, built on demand
+	indices map[*packages.Package]*index
+
+	// packages without initialization, see WalkInit
+	noInit map[*packages.Package]bool
+}
+
+// index holds lookup tables for a single package, replacing
+// linear scans of its TypesInfo and syntax.
+type index struct {
+	defs  map[types.Object]*ast.Ident  // object to its defining ident
+	funcs map[*ast.Ident]*ast.FuncDecl // func or method name to its declaration
+	specs map[*ast.Ident]ast.Spec      // package-level type, const or var name to its spec
+
+	owners map[*types.Var]*types.TypeName // struct field to package-level type declaring it
+}
+
+// NewWalker inits new AST walker for the packages returned by Load.
+//
+// Only Stdlib, Internal, Jobs and Cache options are used by Walker,
+// SSA backend should be set up by caller, see Analyze.
+func NewWalker(pkgs []*packages.Package, opts Options) *Walker {
+	imports := make(map[string]Package)
+	for _, pkg := range pkgs {
+		// prepare map of resolved imports
+		for _, i := range pkg.Imports {
+
+			if !opts.Stdlib && IsStdlib(i.PkgPath) {
+				continue
+			}
+			if !opts.Internal && IsInternal(pkg, i) {
+				continue
+			}
+			imports[i.PkgPath] = PackageOf(i)
+		}
+	}
+
+	all := make(map[string]*packages.Package)
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		// prefer non-test variants of the package, if both are loaded
+		if _, ok := all[pkg.PkgPath]; ok && pkg.ID != pkg.PkgPath {
+			return
+		}
+		all[pkg.PkgPath] = pkg
+	})
+
+	var fset *token.FileSet
+	if len(pkgs) > 0 {
+		fset = pkgs[0].Fset
+	}
+
+	return &Walker{
+		Fset:      fset,
+		Initial:   pkgs,
+		Packages:  imports,
+		CacheSize: make(map[*ast.FuncDecl]FuncSize),
+
+		Options: opts,
+
+		Visited: make(map[ast.Node]*Selector),
+
+		all:     all,
+		indices: make(map[*packages.Package]*index),
+		noInit:  make(map[*packages.Package]bool),
+	}
+}
+
+// Package returns loaded package by its import path, or nil
+// if it wasn't loaded.
+func (w *Walker) Package(path string) *packages.Package {
+	return w.all[path]
+}
+
+// WalkAll walks every initial package into its own Result, looking
+// only for selectors from imported packages, and merges them into
+// aggregate one.
+//
+// Packages are walked by up to Jobs goroutines. Results don't
+// depend on the order packages are walked in, so they're the same
+// for any number of jobs.
+//
+// It stops and returns ctx error if ctx is done before all
+// packages are walked.
+func (w *Walker) WalkAll(ctx context.Context) (*Results, error) {
+	// Result is not safe for concurrent use, so packages sharing
+	// the same import path are walked by the same goroutine.
+	results := NewResults()
+	roots := make(map[string][]*packages.Package)
+	for _, pkg := range w.Roots() {
+		results.Result(pkg.PkgPath)
+		roots[pkg.PkgPath] = append(roots[pkg.PkgPath], pkg)
+	}
+
+	paths := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < min(w.jobs(), len(roots)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				for _, pkg := range roots[path] {
+					w.WalkPackage(pkg, results.Results[path])
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, path := range results.Packages {
+		select {
+		case paths <- path:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(paths)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, path := range results.Packages {
+		results.Aggregate.Merge(results.Results[path])
+	}
+	return results, nil
+}
+
+// jobs returns number of packages to be walked concurrently.
+func (w *Walker) jobs() int {
+	if w.Jobs > 0 {
+		return w.Jobs
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// Roots returns initial packages to be walked.
+//
+// With tests, go/packages returns both package and its test variant,
+// which is a superset of it, and generated test main, so only
+// test variant is walked.
+func (w *Walker) Roots() []*packages.Package {
+	hasTestVariant := make(map[string]bool)
+	for _, pkg := range w.Initial {
+		if pkg.ID != pkg.PkgPath {
+			hasTestVariant[pkg.PkgPath] = true
+		}
+	}
+
+	var ret []*packages.Package
+	for _, pkg := range w.Initial {
+		if pkg.ID == pkg.PkgPath && hasTestVariant[pkg.PkgPath] {
+			continue
+		}
+		if pkg.Name == "main" && strings.HasSuffix(pkg.PkgPath, ".test") {
+			continue
+		}
+		ret = append(ret, pkg)
+	}
+	return ret
+}
+
+// WalkPackage looks for dependencies used in a given package and saves
+// selectors to result.
+//
+// It should be called for the top-level package only.
+// Only external dependencies are added to result.
+func (w *Walker) WalkPackage(pkg *packages.Package, result *Result) {
+	for _, obj := range pkg.TypesInfo.Uses {
+		w.walkUse(pkg, obj, result)
+	}
+
+	// fields of unkeyed composite literals have no identifiers,
+	// but they're used all the same
+	for _, f := range pkg.Syntax {
+		ast.Inspect(f, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.CompositeLit); ok {
+				for _, field := range unkeyed(pkg, lit) {
+					w.walkUse(pkg, field, result)
+				}
+			}
+			return true
+		})
+	}
+
+	// importing a package runs its initialization, whatever is used
+	inits := make(map[string]*Selector)
+	for path, imp := range pkg.Imports {
+		depPkg := w.Package(imp.PkgPath)
+		if depPkg == nil || (!w.Internal && IsInternal(pkg, depPkg)) {
+			continue
+		}
+		if sel := w.WalkInit(depPkg); sel != nil {
+			inits[path] = sel
+			result.Inits[sel.Pkg.ID()] = sel
+		}
+	}
+	for _, f := range pkg.Syntax {
+		for _, spec := range f.Imports {
+			if spec.Name == nil || spec.Name.Name != "_" {
+				continue
+			}
+			path, _ := strconv.Unquote(spec.Path.Value)
+			if sel := inits[path]; sel != nil {
+				result.Blank[sel.Pkg.ID()] = true
+			}
+		}
+	}
+}
+
+// walkUse adds selector for the object of dependency, used
+// by the top-level package, to result.
+func (w *Walker) walkUse(pkg *packages.Package, obj types.Object, result *Result) {
+	if obj.Pkg() == nil || obj.Pkg() == pkg.Types {
+		return
+	}
+
+	if !obj.Exported() {
+		return
+	}
+
+	depPkg := w.Package(obj.Pkg().Path())
+	if depPkg == nil {
+		return
+	}
+
+	// Omit the internal modules
+	if !w.Internal && IsInternal(pkg, depPkg) {
+		return
+	}
+
+	if sel := w.WalkObject(depPkg, obj); sel != nil {
+		result.Add(sel)
+	}
+}
+
+// WalkObject builds Selector from the given pkg and object.
+//
+// It recursively goes into nested functions/calls, and types, consts
+// and vars they refer to, and struct fields they use, adding it as Deps.
+func (w *Walker) WalkObject(pkg *packages.Package, obj types.Object) *Selector {
+	if obj == nil || pkg == nil {
+		return nil
+	}
+
+	if !w.Stdlib && IsStdlib(pkg.PkgPath) {
+		return nil
+	}
+
+	decl, def := w.FindDefDecl(pkg, obj)
+	if def == nil || decl == nil {
+		return nil
+	}
+
+	typ, recv, ok := ObjectKind(def)
+	if !ok {
+		return nil
+	}
+
+	if fn, ok := def.(*types.Func); ok && w.SSA != nil {
+		return w.SSA.WalkFunc(w, PackageOf(pkg), fn)
+	}
+	if v, ok := def.(*types.Var); ok && v.IsField() {
+		return w.walkField(pkg, decl, v)
+	}
+
+	fnDecl := w.FnDecl(pkg, decl)
+	if fnDecl == nil {
+		return w.walkSpec(pkg, decl, recv, typ)
+	}
+
+	size := w.Size(fnDecl)
+	cached := w.cachedFunc(pkg, recv, fnDecl.Name.Name)
+	if cached != nil {
+		size = cached.FuncSize
+	}
+
+	sel := NewSelector(PackageOf(pkg), fnDecl.Name.Name, recv, typ, size.LOC)
+	sel.setSize(size)
+	sel, ok = w.visit(fnDecl, sel)
+	if ok {
+		return sel
+	}
+
+	if cached != nil {
+		sel.Deps = w.walkCached(cached)
+	} else {
+		sel.Deps = w.WalkFuncBody(pkg, fnDecl)
+	}
+
+	return sel
+}
+
+// walkSpec builds Selector for the package-level type, const or var,
+// with types, consts and vars used in its declaration as Deps. Other
+// objects, like interface methods, have no declaration to walk.
+func (w *Walker) walkSpec(pkg *packages.Package, decl *ast.Ident, recv, typ string) *Selector {
+	spec := w.Spec(pkg, decl)
+	if spec == nil {
+		return NewSelector(PackageOf(pkg), decl.Name, recv, typ, 0)
+	}
+
+	size := SpecSize(w.Fset, spec)
+	sel := NewSelector(PackageOf(pkg), decl.Name, recv, typ, size.LOC)
+	sel.setSize(size)
+	sel, ok := w.visit(decl, sel)
+	if ok {
+		return sel
+	}
+
+	var deps Deps
+	for _, dep := range w.walkObjects(w.refs(pkg, spec)) {
+		// recursive types refer to themselves
+		if dep != sel {
+			deps.Append(dep)
+		}
+	}
+	sel.Deps = deps
+
+	return sel
+}
+
+// WalkInit builds Selector for initialization of the package: its init
+// funcs and initializers of package-level vars, which run whenever the
+// package is imported, whatever is used of it. Initialization of
+// packages it imports is a dependency too, as it runs first.
+//
+// Methods of types, values of which are created by initialization,
+// are dependencies as well, as such values are usually registered
+// somewhere, like database/sql drivers, to be called later.
+//
+// It returns nil for packages without initialization, neither
+// their own, nor of packages they import.
+func (w *Walker) WalkInit(pkg *packages.Package) *Selector {
+	if pkg == nil || len(pkg.Syntax) == 0 {
+		return nil
+	}
+
+	if !w.Stdlib && IsStdlib(pkg.PkgPath) {
+		return nil
+	}
+
+	w.mu.Lock()
+	sel, ok := w.Visited[pkg.Syntax[0]]
+	skip := w.noInit[pkg]
+	w.mu.Unlock()
+	if ok || skip {
+		return sel
+	}
+
+	var size FuncSize
+	var nodes []ast.Node
+	for _, f := range pkg.Syntax {
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil && d.Name.Name == "init" {
+					s := w.Size(d)
+					size.LOC += s.LOC
+					size.SLOC += s.SLOC
+					size.Stmts += s.Stmts
+					nodes = append(nodes, d)
+				}
+			case *ast.GenDecl:
+				if d.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range d.Specs {
+					if spec, ok := spec.(*ast.ValueSpec); ok && len(spec.Values) > 0 {
+						s := SpecSize(w.Fset, spec)
+						size.LOC += s.LOC
+						size.SLOC += s.SLOC
+						nodes = append(nodes, spec)
+					}
+				}
+			}
+		}
+	}
+
+	// imports can't be cyclic, so their initialization
+	// is walked before publishing selector
+	var imports Deps
+	var paths []string
+	for path := range pkg.Imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if dep := w.WalkInit(w.Package(pkg.Imports[path].PkgPath)); dep != nil {
+			imports.Append(dep)
+		}
+	}
+	if len(nodes) == 0 && len(imports) == 0 {
+		w.mu.Lock()
+		w.noInit[pkg] = true
+		w.mu.Unlock()
+		return nil
+	}
+
+	sel = NewSelector(PackageOf(pkg), "init", "", "init", size.LOC)
+	sel.setSize(size)
+	// packages have no node of their own, so the first file is used
+	sel, ok = w.visit(pkg.Syntax[0], sel)
+	if ok {
+		return sel
+	}
+
+	var deps Deps
+	for _, node := range nodes {
+		objs := append(w.funcs(pkg, node, true), w.refs(pkg, node)...)
+		objs = append(objs, w.fields(pkg, node)...)
+		for _, dep := range w.walkObjects(append(objs, registered(pkg, node)...)) {
+			deps.Append(dep)
+		}
+	}
+
+	for _, dep := range imports {
+		deps.Append(dep)
+	}
+	sel.Deps = deps
+
+	return sel
+}
+
+// registered returns methods of named types, values of which are
+// created by composite literals in a given node.
+func registered(pkg *packages.Package, node ast.Node) []types.Object {
+	var ret []types.Object
+	seen := make(map[*types.Named]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		typ := pkg.TypesInfo.TypeOf(lit)
+		if p, ok := typ.(*types.Pointer); ok {
+			typ = p.Elem()
+		}
+		named, ok := typ.(*types.Named)
+		if !ok || named.Obj().Pkg() == nil || seen[named.Origin()] {
+			return true
+		}
+		named = named.Origin()
+		seen[named] = true
+		for i := 0; i < named.NumMethods(); i++ {
+			ret = append(ret, named.Method(i))
+		}
+		return true
+	})
+	return ret
+}
+
+// walkField builds Selector for the struct field, grouped under the
+// package-level type declaring it, which is its only dependency, as
+// the field can't be copied without it. Fields of other structs are
+// skipped.
+func (w *Walker) walkField(pkg *packages.Package, decl *ast.Ident, field *types.Var) *Selector {
+	owner := w.index(pkg).owners[field]
+	if owner == nil {
+		return nil
+	}
+
+	sel := NewSelector(PackageOf(pkg), field.Name(), owner.Name(), "field", 0)
+	sel, ok := w.visit(decl, sel)
+	if ok {
+		return sel
+	}
+	sel.Deps = w.walkObjects([]types.Object{owner})
+
+	return sel
+}
+
+// visit returns Selector, visited for the given node before, and true,
+// or publishes sel for it.
+//
+// Selector is published before its body is walked, so recursive
+// calls and other goroutines get it from Visited, instead of
+// waiting for each other.
+func (w *Walker) visit(node ast.Node, sel *Selector) (*Selector, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if s, ok := w.Visited[node]; ok {
+		return s, true
+	}
+	w.Visited[node] = sel
+	return sel, false
+}
+
+// WalkFuncBody searches for all internal or external selectors, used in a given
+// function. It recursively goes into it, building Deps slice.
+func (w *Walker) WalkFuncBody(pkg *packages.Package, node *ast.FuncDecl) Deps {
+	return w.walkObjects(w.uses(pkg, node))
+}
+
+// walkObjects builds Deps from the given objects.
+func (w *Walker) walkObjects(objs []types.Object) Deps {
+	var deps Deps
+	for _, obj := range objs {
+		// may be dot-imported from another package
+		depPkg := w.Package(obj.Pkg().Path())
+		s := w.WalkObject(depPkg, obj)
+		if s != nil {
+			deps.Append(s)
+		}
+	}
+	return deps
+}
+
+// uses returns objects a given function depends on: funcs and
+// methods first, then types, consts and vars it refers to, and
+// struct fields it uses.
+func (w *Walker) uses(pkg *packages.Package, node *ast.FuncDecl) []types.Object {
+	objs := append(w.funcs(pkg, node, true), w.refs(pkg, node)...)
+	return append(objs, w.fields(pkg, node)...)
+}
+
+// funcs returns funcs and methods, referred to in a given node, in
+// order of appearance: called ones, and ones used as values, like
+// method values, method expressions and funcs passed as arguments,
+// as they're likely to be called somewhere else. Called ones are
+// skipped, unless calls is set.
+func (w *Walker) funcs(pkg *packages.Package, node ast.Node, calls bool) []types.Object {
+	var ret []types.Object
+	called := make(map[*ast.Ident]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.CallExpr:
+			switch fun := ast.Unparen(n.Fun).(type) {
+			case *ast.Ident:
+				called[fun] = true
+			case *ast.SelectorExpr:
+				called[fun.Sel] = true
+			}
+		case *ast.Ident:
+			fn, ok := pkg.TypesInfo.Uses[n].(*types.Func)
+			if ok && fn.Pkg() != nil && (calls || !called[n]) {
+				ret = append(ret, fn)
+			}
+		}
+		return true
+	})
+	return ret
+}
+
+// refs returns package-level types, consts and vars, referenced
+// in a given node, in order of appearance. They should be copied
+// along with the code using them, so they're dependencies too.
+func (w *Walker) refs(pkg *packages.Package, node ast.Node) []types.Object {
+	var ret []types.Object
+	seen := make(map[types.Object]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+		obj := pkg.TypesInfo.Uses[ident]
+		if obj == nil || obj.Pkg() == nil || seen[obj] || obj.Parent() != obj.Pkg().Scope() {
+			return true
+		}
+		switch obj.(type) {
+		case *types.TypeName, *types.Const, *types.Var:
+			seen[obj] = true
+			ret = append(ret, obj)
+		}
+		return true
+	})
+	return ret
+}
+
+// fields returns struct fields, read, written or set by composite
+// literals in a given node, in order of appearance.
+func (w *Walker) fields(pkg *packages.Package, node ast.Node) []types.Object {
+	var ret []types.Object
+	seen := make(map[*types.Var]bool)
+	add := func(v *types.Var) {
+		if v.Pkg() != nil && !seen[v] {
+			seen[v] = true
+			ret = append(ret, v)
+		}
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.Ident:
+			if v, ok := pkg.TypesInfo.Uses[n].(*types.Var); ok && v.IsField() {
+				add(v)
+			}
+		case *ast.CompositeLit:
+			for _, v := range unkeyed(pkg, n) {
+				add(v)
+			}
+		}
+		return true
+	})
+	return ret
+}
+
+// unkeyed returns fields, set by unkeyed composite literal of a struct.
+// Such fields have no identifiers, but they're used all the same.
+func unkeyed(pkg *packages.Package, lit *ast.CompositeLit) []*types.Var {
+	if len(lit.Elts) == 0 {
+		return nil
+	}
+	if _, ok := lit.Elts[0].(*ast.KeyValueExpr); ok {
+		return nil
+	}
+	typ := pkg.TypesInfo.TypeOf(lit)
+	if p, ok := typ.(*types.Pointer); ok {
+		typ = p.Elem()
+	}
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return nil
+	}
+	var ret []*types.Var
+	for i := range lit.Elts {
+		if i < st.NumFields() {
+			ret = append(ret, st.Field(i))
+		}
+	}
+	return ret
+}
+
+// FindDefDecl searches for declaration and definition for the given object.
+func (w *Walker) FindDefDecl(pkg *packages.Package, obj types.Object) (*ast.Ident, types.Object) {
+	if obj == nil {
+		return nil, nil
+	}
+	// fields of instantiated generic structs are distinct objects
+	if v, ok := obj.(*types.Var); ok && v.IsField() {
+		obj = v.Origin()
+	}
+	if decl, ok := w.index(pkg).defs[obj]; ok {
+		return decl, obj
+	}
+	return nil, nil
+}
+
+// FnDecl searches for the FuncDecl based on ast.Ident node.
+func (w *Walker) FnDecl(pkg *packages.Package, decl *ast.Ident) *ast.FuncDecl {
+	return w.index(pkg).funcs[decl]
+}
+
+// Spec searches for the package-level type or value spec
+// based on ast.Ident node.
+func (w *Walker) Spec(pkg *packages.Package, decl *ast.Ident) ast.Spec {
+	return w.index(pkg).specs[decl]
+}
+
+// index returns lookup index of the package, building it on first use.
+func (w *Walker) index(pkg *packages.Package) *index {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if idx, ok := w.indices[pkg]; ok {
+		return idx
+	}
+
+	idx := &index{
+		defs:   make(map[types.Object]*ast.Ident, len(pkg.TypesInfo.Defs)),
+		funcs:  make(map[*ast.Ident]*ast.FuncDecl),
+		specs:  make(map[*ast.Ident]ast.Spec),
+		owners: make(map[*types.Var]*types.TypeName),
+	}
+	for ident, obj := range pkg.TypesInfo.Defs {
+		if obj != nil {
+			idx.defs[obj] = ident
+		}
+	}
+	for _, f := range pkg.Syntax {
+		for _, d := range f.Decls {
+			switch d := d.(type) {
+			case *ast.FuncDecl:
+				idx.funcs[d.Name] = d
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						idx.specs[spec.Name] = spec
+						if tn, ok := pkg.TypesInfo.Defs[spec.Name].(*types.TypeName); ok {
+							addOwner(idx.owners, tn, tn.Type().Underlying())
+						}
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							idx.specs[name] = spec
+						}
+					}
+				}
+			}
+		}
+	}
+
+	w.indices[pkg] = idx
+	return idx
+}
+
+// addOwner saves owner as the type declaring fields of the struct,
+// including fields of nested anonymous structs.
+func addOwner(owners map[*types.Var]*types.TypeName, owner *types.TypeName, typ types.Type) {
+	st, ok := typ.(*types.Struct)
+	if !ok {
+		return
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		owners[st.Field(i)] = owner
+		addOwner(owners, owner, st.Field(i).Type())
+	}
+}
+
+// FuncSize holds size and complexity metrics of a function.
+// Only LOC and SLOC apply to other declarations, see SpecSize.
+type FuncSize struct {
+	LOC        int `json:"loc"`        // see FuncLOC
+	SLOC       int `json:"sloc"`       // see FuncSLOC
+	Stmts      int `json:"stmts"`      // see FuncStmts
+	Cyclomatic int `json:"cyclomatic"` // see FuncCyclomatic
+	Cognitive  int `json:"cognitive"`  // see FuncCognitive
+}
+
+// SizeOf calculates size and complexity metrics for the function declaration.
+func SizeOf(fset *token.FileSet, node *ast.FuncDecl) FuncSize {
+	return FuncSize{
+		LOC:        FuncLOC(fset, node),
+		SLOC:       FuncSLOC(fset, node),
+		Stmts:      FuncStmts(node),
+		Cyclomatic: FuncCyclomatic(node),
+		Cognitive:  FuncCognitive(node),
+	}
+}
+
+// Size calculates size and complexity metrics for the given function node.
+func (w *Walker) Size(node *ast.FuncDecl) FuncSize {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if size, ok := w.CacheSize[node]; ok {
+		return size
+	}
+
+	size := SizeOf(w.Fset, node)
+	w.CacheSize[node] = size
+
+	return size
+}
+
+// FuncLOC calculates LOC for the function declaration, or
+// zero if it has no body.
+func FuncLOC(fset *token.FileSet, node *ast.FuncDecl) int {
+	body := node.Body
+	if body == nil {
+		return 0
+	}
+
+	start := fset.Position(body.Lbrace)
+	end := fset.Position(body.Rbrace)
+	lines := end.Line - start.Line
+
+	// for cases line 'func foo() { bar() }'
+	// TODO: figure out how to calculate it smarter
+	if lines == 0 {
+		lines = 1
+	}
+
+	return lines
+}
+
+// FuncSLOC calculates Source Lines Of Code for the function
+// declaration: lines of its body with any code, so blank lines,
+// comments and braces of the body itself are not counted.
+func FuncSLOC(fset *token.FileSet, node *ast.FuncDecl) int {
+	if node.Body == nil {
+		return 0
+	}
+
+	lines := make(map[int]bool)
+	for _, stmt := range node.Body.List {
+		codeLines(fset, stmt, lines)
+	}
+	return len(lines)
+}
+
+// codeLines marks lines of the node with any code in lines.
+func codeLines(fset *token.FileSet, node ast.Node, lines map[int]bool) {
+	// every line with code has a node, starting or ending on it
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n.(type) {
+		case nil, *ast.CommentGroup:
+			return false
+		}
+		lines[fset.Position(n.Pos()).Line] = true
+		lines[fset.Position(n.End()-1).Line] = true
+		return true
+	})
+}
+
+// SpecSize calculates size of the type, const or var spec: all
+// lines of it as LOC, and lines with code as SLOC.
+func SpecSize(fset *token.FileSet, spec ast.Spec) FuncSize {
+	lines := make(map[int]bool)
+	codeLines(fset, spec, lines)
+	start := fset.Position(spec.Pos())
+	end := fset.Position(spec.End())
+	return FuncSize{
+		LOC:  end.Line - start.Line + 1,
+		SLOC: len(lines),
+	}
+}
+
+// FuncStmts counts statements in the function body, including
+// nested ones and those of function literals. Blocks are not
+// counted, as they only group statements.
+func FuncStmts(node *ast.FuncDecl) int {
+	if node.Body == nil {
+		return 0
+	}
+
+	var count int
+	ast.Inspect(node.Body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.BlockStmt, *ast.EmptyStmt:
+		case ast.Stmt:
+			count++
+		}
+		return true
+	})
+	return count
+}
+
+// LookupObject searches for the object referred by ast.Ident node
+// in current package.
+func (w *Walker) LookupObject(pkg *packages.Package, expr *ast.Ident) types.Object {
+	return pkg.TypesInfo.ObjectOf(expr)
+}
+
+// ObjectKind returns selector type and receiver for the object.
+// Fields have no receiver, as it's the type declaring them, which
+// is not known from the object itself. It returns false for objects
+// that can't be selectors, like packages, labels and builtins.
+func ObjectKind(obj types.Object) (typ, recv string, ok bool) {
+	switch d := obj.(type) {
+	case *types.Const:
+		typ = "const"
+	case *types.Var:
+		typ = "var"
+		if d.IsField() {
+			typ = "field"
+		}
+	case *types.Func:
+		typ = "func"
+		if r := d.Type().(*types.Signature).Recv(); r != nil {
+			typ = "method"
+			recv = printType(r.Type())
+		}
+	case *types.TypeName:
+		typ = "type"
+		if _, ok := d.Type().Underlying().(*types.Interface); ok {
+			typ = "interface"
+		}
+	default:
+		return "", "", false
+	}
+	return typ, recv, true
+}
+
+func printType(t types.Type) string {
+	switch t := t.(type) {
+	case *types.Pointer:
+		return fmt.Sprintf("*%s", printType(t.Elem()))
+	case *types.Named:
+		return t.Obj().Name()
+	}
+	return t.String()
+}
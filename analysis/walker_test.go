@@ -0,0 +1,138 @@
+package analysis
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// bigLibPath is an import path of the synthetic dependency, see loadBigFixture.
+const bigLibPath = "example.com/big/lib"
+
+func TestBigFixture(t *testing.T) {
+	pkgs := loadBigFixture(t, 10)
+	results, err := NewWalker(pkgs, Options{Internal: true}).WalkAll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 10 funcs and 10 consts are used
+	checkCount("big", t, results.Aggregate, 20)
+	checkSelector("big", t, results.Aggregate, bigLibPath+".func.F3", 1, 4, 14, 0, 2)
+}
+
+func BenchmarkWalk(b *testing.B) {
+	for _, n := range []int{500, 2000} {
+		b.Run(fmt.Sprintf("funcs=%d", n), func(b *testing.B) {
+			pkgs := loadBigFixture(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				w := NewWalker(pkgs, Options{Internal: true})
+				if _, err := w.WalkAll(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFindDefDecl(b *testing.B) {
+	pkgs := loadBigFixture(b, 2000)
+	lib := pkgs[0].Imports[bigLibPath]
+	var objs []types.Object
+	for _, obj := range lib.TypesInfo.Defs {
+		if obj != nil {
+			objs = append(objs, obj)
+		}
+	}
+
+	b.Run("index", func(b *testing.B) {
+		w := NewWalker(pkgs, Options{Internal: true})
+		for i := 0; i < b.N; i++ {
+			w.FindDefDecl(lib, objs[i%len(objs)])
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scanDefs(lib, objs[i%len(objs)])
+		}
+	})
+}
+
+// scanDefs is a linear scan of package definitions, which was used
+// before indexing, for comparison.
+func scanDefs(pkg *packages.Package, obj types.Object) *ast.Ident {
+	for decl, def := range pkg.TypesInfo.Defs {
+		if def == obj {
+			return decl
+		}
+	}
+	return nil
+}
+
+// loadBigFixture generates and loads module with a package, using
+// n funcs and consts of the lib package. Every func of lib calls
+// its own helper, and helper of func n/2, so helpers are shared.
+func loadBigFixture(tb testing.TB, n int) []*packages.Package {
+	dir := tb.TempDir()
+
+	var lib, app strings.Builder
+	lib.WriteString("package lib\n\n")
+	app.WriteString("package main\n\nimport \"example.com/big/lib\"\n\nfunc main() {\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&lib, "const C%d = %d\n\n", i, i)
+		fmt.Fprintf(&lib, "type T%d struct{ a, b int }\n\n", i)
+		fmt.Fprintf(&lib, "func F%d(x int) int {\n\ty := h%d(x)\n\ty += h%d(x)\n\treturn y\n}\n\n", i, i, i/2)
+		fmt.Fprintf(&lib, "func h%d(x int) int {\n\tt := T%d{a: x}\n\tt.b = t.a * 2\n\treturn t.b\n}\n\n", i, i)
+		fmt.Fprintf(&app, "\t_ = lib.F%d(lib.C%d)\n", i, i)
+	}
+	app.WriteString("}\n")
+
+	writeFiles(tb, dir, map[string]string{
+		"go.mod":      "module example.com/big\n\ngo 1.25\n",
+		"lib/lib.go":  lib.String(),
+		"app/main.go": app.String(),
+	})
+
+	pkgs, err := Load(context.Background(), dir, false, "./app")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return pkgs
+}
+
+// writeFiles writes files with slash-separated names into dir.
+func writeFiles(tb testing.TB, dir string, files map[string]string) {
+	for name, data := range files {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			tb.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+			tb.Fatal(err)
+		}
+	}
+}
+
+func TestObjectKind(t *testing.T) {
+	pkg := types.NewPackage("example.com/p", "p")
+	if typ, _, ok := ObjectKind(types.NewConst(0, pkg, "C", types.Typ[types.Int], nil)); !ok || typ != "const" {
+		t.Fatalf("expected const to be supported, but got %q, %v", typ, ok)
+	}
+	for _, obj := range []types.Object{
+		types.NewPkgName(0, pkg, "p", pkg),
+		types.NewLabel(0, pkg, "L"),
+		types.Universe.Lookup("len"),
+	} {
+		if _, _, ok := ObjectKind(obj); ok {
+			t.Fatalf("expected %v to be unsupported", obj)
+		}
+	}
+}
@@ -0,0 +1,416 @@
+// Package analyzer provides depscheck as a go/analysis Analyzer,
+// so it could be run by 'go vet -vettool', golangci-lint, gopls
+// and any other analysis driver.
+//
+// Unlike the analysis package, which loads the whole program at
+// once, Analyzer sees a single package at a time: LOC and depth of
+// every function, type, const and var are exported as FuncFact, so
+// they're available when analyzing importers. Dependencies that could be avoided
+// (see analysis.PackageStat.CanBeAvoided) are reported at their
+// import specs.
+//
+// Package stats are a subset of analysis.Result.PackagesStats: cost of
+// initialization of dependencies isn't computed (so the init threshold
+// has no effect), used struct fields don't add LOC of their owner types,
+// and config overrides and ignore lists aren't supported, as there is
+// no config file. The CLI may thus report more than Analyzer does.
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+
+	depscheck "github.com/divan/depscheck/analysis"
+	"golang.org/x/tools/go/analysis"
+)
+
+const doc = `report dependencies that are small enough to be copied
+
+A little copying is better than a little dependency. The depscheck
+analyzer reports imports of packages of which only a few small
+functions, types and constants are used, so they could be copied
+into the project instead.`
+
+// Analyzer reports avoidable dependencies.
+var Analyzer = &analysis.Analyzer{
+	Name:      "depscheck",
+	Doc:       doc,
+	URL:       "https://github.com/divan/depscheck",
+	Run:       run,
+	FactTypes: []analysis.Fact{new(FuncFact), new(ModuleFact)},
+}
+
+var (
+	stdlib     bool
+	internal   bool
+	thresholds = depscheck.DefaultThresholds
+)
+
+func init() {
+	Analyzer.Flags.BoolVar(&stdlib, "stdlib", false, "Treat stdlib packages as external dependencies")
+	Analyzer.Flags.BoolVar(&internal, "internal", false, "Report packages of the same module too")
+	Analyzer.Flags.Var(&thresholds, "thresholds", "Thresholds for reporting package (loc=N,count=N,depth=N,depthint=N,cyclo=N,cognit=N,metric=loc|sloc|stmts); init=N is accepted, but has no effect, as initialization isn't analyzed")
+}
+
+// FuncFact holds stats of the function, method or package-level
+// type, const or var, including all functions it calls and types,
+// consts and vars it refers to, like analysis.Selector does.
+// Complexity is the max one among these functions.
+type FuncFact struct {
+	LOC           int
+	LOCCum        int
+	SLOCCum       int
+	StmtsCum      int
+	Depth         int
+	DepthInternal int
+	CyclomaticMax int
+	CognitiveMax  int
+}
+
+// AFact implements analysis.Fact.
+func (*FuncFact) AFact() {}
+
+// String implements Stringer for FuncFact.
+func (f *FuncFact) String() string {
+	return fmt.Sprintf("loc=%d loccum=%d sloccum=%d stmtscum=%d depth=%d depthint=%d cyclo=%d cognit=%d",
+		f.LOC, f.LOCCum, f.SLOCCum, f.StmtsCum, f.Depth, f.DepthInternal, f.CyclomaticMax, f.CognitiveMax)
+}
+
+// ModuleFact holds module of the package, so importers could
+// tell dependencies of the same project from external ones,
+// like analysis.IsInternal does.
+type ModuleFact struct {
+	Path string
+	Main bool
+}
+
+// AFact implements analysis.Fact.
+func (*ModuleFact) AFact() {}
+
+// String implements Stringer for ModuleFact.
+func (f *ModuleFact) String() string {
+	if f.Main {
+		return fmt.Sprintf("module=%s main", f.Path)
+	}
+	return fmt.Sprintf("module=%s", f.Path)
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	if mod := pass.Module; mod != nil && mod.Path != "" {
+		pass.ExportPackageFact(&ModuleFact{Path: mod.Path, Main: mod.Main})
+	}
+
+	s := &summarizer{
+		pass:  pass,
+		decls: make(map[types.Object]ast.Node),
+		deps:  make(map[types.Object][]types.Object),
+		facts: make(map[types.Object]*FuncFact),
+	}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.FuncDecl:
+				if obj, ok := pass.TypesInfo.Defs[decl.Name].(*types.Func); ok {
+					s.decls[obj] = decl
+				}
+			case *ast.GenDecl:
+				for _, spec := range decl.Specs {
+					switch spec := spec.(type) {
+					case *ast.TypeSpec:
+						s.addSpec(spec.Name, spec)
+					case *ast.ValueSpec:
+						for _, name := range spec.Names {
+							s.addSpec(name, spec)
+						}
+					}
+				}
+			}
+		}
+	}
+	s.summarize()
+
+	for obj, fact := range s.facts {
+		if obj.Exported() {
+			pass.ExportObjectFact(obj, fact)
+		}
+	}
+
+	for _, stat := range packagesStats(pass, s) {
+		if !stat.CanBeAvoided(thresholds) {
+			continue
+		}
+		for _, spec := range importSpecs(pass, stat.Path) {
+			pass.Reportf(spec.Pos(), "package %s (%s) is a good candidate for removing from dependencies: only %d %s used, through %d selectors, with %d level of nesting",
+				stat.Name, stat.Path, stat.Size(thresholds.Metric), depscheck.MetricName(thresholds.Metric), stat.DepsCount, stat.DepthInternal)
+		}
+	}
+
+	return nil, nil
+}
+
+// summarizer computes FuncFact for functions, types, consts and vars
+// of the package, using facts of imported packages for objects they use.
+type summarizer struct {
+	pass  *analysis.Pass
+	decls map[types.Object]ast.Node // *ast.FuncDecl or ast.Spec
+	deps  map[types.Object][]types.Object
+	facts map[types.Object]*FuncFact
+}
+
+// addSpec adds package-level type, const or var, declared by spec.
+func (s *summarizer) addSpec(name *ast.Ident, spec ast.Spec) {
+	obj := s.pass.TypesInfo.Defs[name]
+	if obj == nil || obj.Parent() != s.pass.Pkg.Scope() {
+		return
+	}
+	s.decls[obj] = spec
+}
+
+// summarize computes facts for all functions, types, consts
+// and vars of the package.
+//
+// Mutually recursive functions are collapsed into a single node,
+// as analysis.Selector does, so they share the same LOCCum.
+func (s *summarizer) summarize() {
+	var roots []types.Object
+	for obj, decl := range s.decls {
+		roots = append(roots, obj)
+		// like Walker.walkSpec, only types, consts and vars
+		// are dependencies of specs
+		_, isFunc := obj.(*types.Func)
+		s.deps[obj] = s.uses(decl, isFunc)
+	}
+	// order doesn't affect facts, but keeps the walk reproducible
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].Pos() < roots[j].Pos()
+	})
+
+	local := func(obj types.Object) []types.Object {
+		var ret []types.Object
+		for _, dep := range s.deps[obj] {
+			if s.decls[dep] != nil {
+				ret = append(ret, dep)
+			}
+		}
+		return ret
+	}
+
+	for _, comp := range depscheck.StronglyConnected(roots, local) {
+		in := make(map[types.Object]bool)
+		for _, obj := range comp {
+			in[obj] = true
+		}
+
+		shared := FuncFact{DepthInternal: len(comp) - 1}
+		for _, obj := range comp {
+			size := s.size(obj)
+			shared.LOCCum += size.LOC
+			shared.SLOCCum += size.SLOC
+			shared.StmtsCum += size.Stmts
+			shared.CyclomaticMax = max(shared.CyclomaticMax, size.Cyclomatic)
+			shared.CognitiveMax = max(shared.CognitiveMax, size.Cognitive)
+			for _, dep := range s.deps[obj] {
+				if in[dep] {
+					continue
+				}
+				f := s.summary(dep)
+				if f == nil {
+					continue
+				}
+				shared.LOCCum += f.LOCCum
+				shared.SLOCCum += f.SLOCCum
+				shared.StmtsCum += f.StmtsCum
+				shared.CyclomaticMax = max(shared.CyclomaticMax, f.CyclomaticMax)
+				shared.CognitiveMax = max(shared.CognitiveMax, f.CognitiveMax)
+				// types, consts and vars don't nest calls
+				if _, ok := dep.(*types.Func); !ok {
+					continue
+				}
+				if dep.Pkg() != obj.Pkg() {
+					shared.Depth += 1 + f.Depth
+				} else {
+					shared.DepthInternal += 1 + f.DepthInternal
+				}
+			}
+		}
+
+		for _, obj := range comp {
+			fact := shared
+			if decl, ok := s.decls[obj].(*ast.FuncDecl); ok {
+				fact.LOC = depscheck.FuncLOC(s.pass.Fset, decl)
+			} else {
+				// see analysis.Selector.Depth
+				fact.LOC = s.size(obj).LOC
+				fact.Depth, fact.DepthInternal = 0, 0
+			}
+			s.facts[obj] = &fact
+		}
+	}
+}
+
+// size returns size of the function or spec, declaring obj.
+func (s *summarizer) size(obj types.Object) depscheck.FuncSize {
+	switch decl := s.decls[obj].(type) {
+	case *ast.FuncDecl:
+		return depscheck.SizeOf(s.pass.Fset, decl)
+	case ast.Spec:
+		return depscheck.SpecSize(s.pass.Fset, decl)
+	}
+	return depscheck.FuncSize{}
+}
+
+// summary returns FuncFact for object, or nil if it's unknown.
+func (s *summarizer) summary(obj types.Object) *FuncFact {
+	if fn, ok := obj.(*types.Func); ok {
+		obj = fn.Origin()
+	}
+	if obj.Pkg() != s.pass.Pkg {
+		var fact FuncFact
+		if !s.pass.ImportObjectFact(obj, &fact) {
+			return nil
+		}
+		return &fact
+	}
+	return s.facts[obj]
+}
+
+// uses returns functions called or referred to as values in the node,
+// if funcs is true, and package-level types, consts and vars it refers
+// to, in order of appearance, the same way Walker.WalkFuncBody does.
+func (s *summarizer) uses(node ast.Node, funcs bool) []types.Object {
+	var ret []types.Object
+	seen := make(map[types.Object]bool)
+	ast.Inspect(node, func(n ast.Node) bool {
+		ident, ok := n.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		obj := s.pass.TypesInfo.Uses[ident]
+		switch o := obj.(type) {
+		case *types.Func:
+			if !funcs {
+				return true
+			}
+			obj = o.Origin()
+		case *types.TypeName, *types.Const, *types.Var:
+			if obj.Pkg() == nil || obj.Parent() != obj.Pkg().Scope() {
+				return true
+			}
+		default:
+			return true
+		}
+		if obj.Pkg() == nil || seen[obj] {
+			return true
+		}
+		if !stdlib && depscheck.IsStdlib(obj.Pkg().Path()) {
+			return true
+		}
+		seen[obj] = true
+		ret = append(ret, obj)
+		return true
+	})
+	return ret
+}
+
+// packagesStats returns stats of packages, used by the package
+// being analyzed, sorted by path.
+func packagesStats(pass *analysis.Pass, s *summarizer) []*depscheck.PackageStat {
+	stats := make(map[string]*depscheck.PackageStat)
+	used := make(map[types.Object]bool)
+	for _, obj := range pass.TypesInfo.Uses {
+		if obj.Pkg() == nil || obj.Pkg() == pass.Pkg || !obj.Exported() {
+			continue
+		}
+		if _, _, ok := depscheck.ObjectKind(obj); !ok {
+			continue
+		}
+		if fn, ok := obj.(*types.Func); ok {
+			obj = fn.Origin()
+		}
+
+		path := obj.Pkg().Path()
+		if !stdlib && depscheck.IsStdlib(path) {
+			continue
+		}
+		if !internal && isInternal(pass, obj.Pkg()) {
+			continue
+		}
+
+		stat, ok := stats[path]
+		if !ok {
+			stat = depscheck.NewPackageStat(depscheck.Package{Name: obj.Pkg().Name(), Path: path})
+			stats[path] = stat
+		}
+		stat.DepsCallsCount++
+		// fields are parts of their types, see analysis.Result.PackagesStats
+		if v, ok := obj.(*types.Var); ok && v.IsField() {
+			continue
+		}
+		if used[obj] {
+			continue
+		}
+		used[obj] = true
+		stat.DepsCount++
+
+		if fact := s.summary(obj); fact != nil {
+			stat.LOCCum += fact.LOCCum
+			stat.SLOCCum += fact.SLOCCum
+			stat.StmtsCum += fact.StmtsCum
+			stat.Depth += fact.Depth
+			stat.DepthInternal += fact.DepthInternal
+			stat.CyclomaticMax = max(stat.CyclomaticMax, fact.CyclomaticMax)
+			stat.CognitiveMax = max(stat.CognitiveMax, fact.CognitiveMax)
+		}
+	}
+
+	var ret []*depscheck.PackageStat
+	for _, stat := range stats {
+		ret = append(ret, stat)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Path < ret[j].Path
+	})
+	return ret
+}
+
+// isInternal returns true if dep belongs to the same project as
+// the package being analyzed, see analysis.IsInternal.
+//
+// Module of dep is known from its ModuleFact. Without module info
+// (GOPATH mode, or drivers not providing it) dep is internal only
+// if it's a subpackage of the package being analyzed.
+func isInternal(pass *analysis.Pass, dep *types.Package) bool {
+	if depscheck.IsStdlib(pass.Pkg.Path()) || depscheck.IsStdlib(dep.Path()) {
+		return false
+	}
+
+	var depMod ModuleFact
+	mod := pass.Module
+	if mod == nil || mod.Path == "" || !pass.ImportPackageFact(dep, &depMod) {
+		return strings.HasPrefix(dep.Path(), pass.Pkg.Path()+"/")
+	}
+
+	if mod.Path == depMod.Path {
+		return true
+	}
+
+	return mod.Main && depMod.Main
+}
+
+// importSpecs returns all import specs of the package with given path.
+func importSpecs(pass *analysis.Pass, path string) []*ast.ImportSpec {
+	var ret []*ast.ImportSpec
+	for _, file := range pass.Files {
+		for _, spec := range file.Imports {
+			if p, err := strconv.Unquote(spec.Path.Value); err == nil && p == path {
+				ret = append(ret, spec)
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,16 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "small", "big", "a")
+}
+
+func TestAnalyzerModules(t *testing.T) {
+	analysistest.Run(t, filepath.Join(analysistest.TestData(), "mod"), Analyzer, "./a")
+}
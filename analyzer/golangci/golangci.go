@@ -0,0 +1,73 @@
+// Package golangci registers depscheck analyzer as a golangci-lint
+// module plugin. Add it to .custom-gcl.yml:
+//
+//	version: v2.1.0
+//	plugins:
+//	  - module: github.com/divan/depscheck
+//	    import: github.com/divan/depscheck/analyzer/golangci
+//	    version: latest
+//
+// and enable in .golangci.yml, with optional settings:
+//
+//	linters:
+//	  enable:
+//	    - depscheck
+//	  settings:
+//	    custom:
+//	      depscheck:
+//	        type: module
+//	        settings:
+//	          thresholds: loc=60,count=3
+package golangci
+
+import (
+	"fmt"
+
+	"github.com/divan/depscheck/analyzer"
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+)
+
+func init() {
+	register.Plugin("depscheck", New)
+}
+
+// Settings are plugin settings, mirroring analyzer flags.
+type Settings struct {
+	Stdlib     bool   `json:"stdlib"`
+	Internal   bool   `json:"internal"`
+	Thresholds string `json:"thresholds"`
+}
+
+type plugin struct{}
+
+// New creates plugin with given settings.
+func New(settings any) (register.LinterPlugin, error) {
+	s, err := register.DecodeSettings[Settings](settings)
+	if err != nil {
+		return nil, err
+	}
+
+	flags := map[string]string{
+		"stdlib":     fmt.Sprint(s.Stdlib),
+		"internal":   fmt.Sprint(s.Internal),
+		"thresholds": s.Thresholds,
+	}
+	for name, value := range flags {
+		if err := analyzer.Analyzer.Flags.Set(name, value); err != nil {
+			return nil, fmt.Errorf("depscheck: invalid %s: %v", name, err)
+		}
+	}
+
+	return &plugin{}, nil
+}
+
+// BuildAnalyzers implements register.LinterPlugin.
+func (*plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	return []*analysis.Analyzer{analyzer.Analyzer}, nil
+}
+
+// GetLoadMode implements register.LinterPlugin.
+func (*plugin) GetLoadMode() string {
+	return register.LoadModeTypesInfo
+}
@@ -0,0 +1,10 @@
+package a // want package:"module=example.com/mod main"
+
+import (
+	"example.com/mod/nested" // want `package nested \(example.com/mod/nested\) is a good candidate for removing from dependencies`
+	"example.com/mod/small"
+)
+
+func A() int { // want A:"loc=2 loccum=6 sloccum=3 stmtscum=3 depth=2 depthint=0 cyclo=1 cognit=0"
+	return small.Inc(nested.Twice(1))
+}
@@ -0,0 +1,7 @@
+module example.com/mod
+
+go 1.21
+
+require example.com/mod/nested v0.0.0
+
+replace example.com/mod/nested => ./nested
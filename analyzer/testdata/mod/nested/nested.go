@@ -0,0 +1,7 @@
+// Package nested is a separate module, nested into the directory
+// of example.com/mod, so it's external despite its import path.
+package nested
+
+func Twice(n int) int {
+	return n * 2
+}
@@ -0,0 +1,6 @@
+// Package small is a package of the same module, so it's internal.
+package small
+
+func Inc(n int) int {
+	return n + 1
+}
@@ -0,0 +1,12 @@
+package a
+
+import (
+	"big"
+	"small" // want `package small \(small\) is a good candidate for removing from dependencies: only 6 LOC used, through 2 selectors, with 1 level of nesting`
+)
+
+func A() int { // want A:"loc=4 loccum=29 sloccum=21 stmtscum=18 depth=4 depthint=0 cyclo=2 cognit=1"
+	_ = small.Pad("a")
+	_ = big.Even(2)
+	return big.Do() + small.Answer
+}
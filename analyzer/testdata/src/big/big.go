@@ -0,0 +1,23 @@
+package big
+
+import "small" // want `package small \(small\) is a good candidate for removing from dependencies: only 5 LOC used, through 1 selectors, with 1 level of nesting`
+
+func Do() int { // want Do:"loc=4 loccum=9 sloccum=6 stmtscum=6 depth=1 depthint=0 cyclo=1 cognit=0"
+	x := 1
+	small.Pad("")
+	return x
+}
+
+func Even(n int) bool { // want Even:"loc=5 loccum=10 sloccum=8 stmtscum=6 depth=0 depthint=1 cyclo=2 cognit=1"
+	if n == 0 {
+		return true
+	}
+	return odd(n - 1)
+}
+
+func odd(n int) bool {
+	if n == 0 {
+		return false
+	}
+	return Even(n - 1)
+}
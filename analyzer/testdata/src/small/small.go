@@ -0,0 +1,24 @@
+package small
+
+const Answer = 42 // want Answer:"loc=1 loccum=1 sloccum=1 stmtscum=0 depth=0 depthint=0 cyclo=0 cognit=0"
+
+func Pad(s string) string { // want Pad:"loc=2 loccum=5 sloccum=3 stmtscum=3 depth=0 depthint=1 cyclo=1 cognit=0"
+	return pad(s)
+}
+
+func pad(s string) string {
+	s = " " + s
+	return s
+}
+
+// Padder refers to pad without calling it, which is a dependency all the same.
+func Padder() func(string) string { // want Padder:"loc=2 loccum=5 sloccum=3 stmtscum=3 depth=0 depthint=1 cyclo=1 cognit=0"
+	return pad
+}
+
+type Width int // want Width:"loc=1 loccum=1 sloccum=1 stmtscum=0 depth=0 depthint=0 cyclo=0 cognit=0"
+
+// Widen uses Width and Answer, which are its dependencies as well.
+func Widen(w Width) Width { // want Widen:"loc=2 loccum=4 sloccum=3 stmtscum=1 depth=0 depthint=0 cyclo=1 cognit=0"
+	return w + Answer
+}
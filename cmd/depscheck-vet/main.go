@@ -0,0 +1,16 @@
+// Command depscheck-vet runs depscheck analyzer as a vet tool:
+//
+//	go install github.com/divan/depscheck/cmd/depscheck-vet@latest
+//	go vet -vettool=$(which depscheck-vet) ./...
+//
+// Analyzer flags are prefixed with its name, like -depscheck.thresholds.
+package main
+
+import (
+	"github.com/divan/depscheck/analyzer"
+	"golang.org/x/tools/go/analysis/unitchecker"
+)
+
+func main() {
+	unitchecker.Main(analyzer.Analyzer)
+}
@@ -0,0 +1,16 @@
+module github.com/divan/depscheck
+
+go 1.25.0
+
+require (
+	github.com/golangci/plugin-module-register v0.1.2
+	github.com/olekukonko/tablewriter v0.0.5
+	golang.org/x/tools v0.47.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/mattn/go-runewidth v0.0.9 // indirect
+	golang.org/x/mod v0.37.0 // indirect
+	golang.org/x/sync v0.21.0 // indirect
+)
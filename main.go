@@ -1,67 +1,441 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"runtime"
+	"strings"
 
-	"golang.org/x/tools/go/loader"
+	"github.com/divan/depscheck/analysis"
 )
 
 var (
-	stdlib   = flag.Bool("stdlib", false, "Treat stdlib packages as external dependencies")
-	tests    = flag.Bool("tests", false, "Include tests for deps analysis")
-	verbose  = flag.Bool("v", false, "Be verbose and print whole deps info table")
-	totals   = flag.Bool("totalonly", false, "Print only totals stats")
-	internal = flag.Bool("internal", false, "Include intertanl packages analysis")
+	stdlib     = flag.Bool("stdlib", false, "Treat stdlib packages as external dependencies")
+	tests      = flag.Bool("tests", false, "Include tests for deps analysis")
+	verbose    = flag.Bool("v", false, "Be verbose and print whole deps info table")
+	totals     = flag.Bool("totalonly", false, "Print only totals stats")
+	internal   = flag.Bool("internal", false, "Include intertanl packages analysis")
+	format     = flag.String("format", "text", "Output format: text or json")
+	backend    = flag.String("backend", analysis.BackendAST, "Analysis backend: ast or ssa (sees calls through interfaces and func values)")
+	cgAlgo     = flag.String("callgraph", analysis.CallGraphVTA, "Call graph algorithm for ssa backend: cha, rta or vta")
+	failOn     = flag.String("fail-on", "", "Comma separated list of policy rules to fail on (candidate,loc=N,sloc=N,stmts=N,count=N,calls=N,depth=N,depthint=N,cyclo=N,cognit=N,init=N)")
+	thresholds = flag.String("thresholds", "", "Thresholds for suggesting package removal (loc=N,count=N,depth=N,depthint=N,cyclo=N,cognit=N,init=N,metric=loc|sloc|stmts)")
+	policyFile = flag.String("policy", "", "Read policy rules and thresholds from JSON file")
+	baseline   = flag.String("baseline", "", "Report only regressions compared to the baseline file")
+	writeBase  = flag.String("write-baseline", "", "Write current result as a baseline file and exit")
+	configFile = flag.String("config", "", "Project config file (default: .depscheck.yml or .depscheck.json in the module root)")
+	jobs       = flag.Int("j", runtime.GOMAXPROCS(0), "Number of packages to analyze in parallel")
+	noCache    = flag.Bool("no-cache", false, "Don't use on-disk cache of dependencies from the module cache")
+)
+
+// Exit codes.
+const (
+	exitOK        = 0
+	exitViolation = 1 // policy violated
+	exitError     = 2 // invalid usage or failed to analyze
 )
 
 func main() {
 	flag.Usage = Usage
 	flag.Parse()
 
-	var conf loader.Config
+	os.Exit(run())
+}
+
+func run() int {
+	if flag.Arg(0) == "cache" {
+		return runCache(flag.Args()[1:])
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown output format: %s\n", *format)
+		return exitError
+	}
+	if *backend != analysis.BackendAST && *backend != analysis.BackendSSA {
+		fmt.Fprintf(os.Stderr, "Unknown analysis backend: %s\n", *backend)
+		return exitError
+	}
+
+	policy, err := readPolicy(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	conf.FromArgs(flag.Args(), *tests)
-	p, err := conf.Load()
+	if flag.Arg(0) == "diff" {
+		return runDiff(ctx, cfg, flag.Args()[1:])
+	}
+	if flag.Arg(0) == "extract" {
+		return runExtract(ctx, cfg, flag.Args()[1:])
+	}
+	if flag.Arg(0) == "interfaces" {
+		return runInterfaces(ctx, cfg, flag.Args()[1:])
+	}
+
+	results, topPackage, err := analyze(ctx, cfg, "", flag.Args())
 	if err != nil {
 		fmt.Println(err)
-		return
+		return exitError
 	}
+	results.Each(func(r *analysis.Result) {
+		r.Thresholds = policy.Thresholds
+	})
+	result := results.Aggregate
 
-	w := NewWalker(p, *stdlib, *internal)
+	if *writeBase != "" {
+		if err := analysis.NewBaseline(result).Write(*writeBase); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		fmt.Fprintf(os.Stderr, "Baseline written to %s\n", *writeBase)
+		return exitOK
+	}
+	if *baseline != "" {
+		b, err := analysis.LoadBaseline(*baseline)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		result.Regressions = b.Compare(result)
+	}
 
-	result := w.TopWalk()
+	violations := policy.Check(result)
+	code := exitOK
+	if len(violations) > 0 {
+		code = exitViolation
+	}
 
 	// Output results
-	topPackage := p.InitialPackages()[0].Pkg.Path()
+	if *format == "json" {
+		// Do not report suggestions in stdlib mode, see below.
+		report := results.JSON(topPackage, !*stdlib)
+		report.AddViolations(violations)
+		if err := report.Write(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		return code
+	}
+
 	fmt.Println(result.Totals(topPackage))
+	if result.Regressions != nil {
+		printRegressions(result.Regressions)
+	}
 	if *totals {
-		return
+		printViolations(violations)
+		return code
+	}
+	if len(results.Packages) > 1 {
+		printTotals(results)
 	}
+	printBlankImports(result)
 	if len(result.Counter) == 0 {
 		fmt.Println("No external dependencies found in this package")
-		return
+		return code
 	}
 	if *verbose {
-		result.PrintStats()
-		result.PrintPackagesStats()
+		printStats(result)
+		printPackagesStats(result)
+		if len(results.Packages) > 1 {
+			printUsedBy(results)
+		}
+		printCycles(result)
 	}
 
 	// Do not report suggestions in stdlib mode.
 	// Stlib is smarter than this tool.
 	if !*stdlib {
-		result.Suggestions()
+		printSuggestions(result)
 	}
 
+	printViolations(violations)
+
 	if !*verbose {
 		fmt.Println("Run with -v option to see detailed stats for dependencies.")
 	}
+
+	return code
+}
+
+// analyze analyzes packages in dir with options from flags
+// and config, returning results with name of the analyzed
+// packages: import path for a single package or patterns otherwise.
+func analyze(ctx context.Context, cfg *analysis.Config, dir string, patterns []string) (*analysis.Results, string, error) {
+	results, err := analysis.Analyze(ctx, options(cfg, dir), patterns...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	name := strings.Join(patterns, " ")
+	switch {
+	case len(results.Packages) == 1:
+		name = results.Packages[0]
+	case name == "":
+		name = "."
+	}
+
+	return results, name, nil
+}
+
+// options builds analysis options for packages in dir
+// from flags and config.
+func options(cfg *analysis.Config, dir string) analysis.Options {
+	opts := analysis.Options{
+		Dir:       dir,
+		Tests:     *tests,
+		Stdlib:    *stdlib,
+		Internal:  *internal,
+		Backend:   *backend,
+		CallGraph: *cgAlgo,
+		Ignore:    cfg.Ignore,
+		Overrides: cfg.Overrides,
+		Jobs:      *jobs,
+	}
+	if !*noCache {
+		if dir, err := analysis.DefaultCacheDir(); err == nil {
+			opts.Cache = analysis.NewCache(dir)
+		}
+	}
+	return opts
+}
+
+// runCache implements 'cache' command.
+func runCache(args []string) int {
+	if len(args) != 1 || args[0] != "clean" {
+		fmt.Fprintln(os.Stderr, "Usage: depscheck cache clean")
+		return exitError
+	}
+
+	dir, err := analysis.DefaultCacheDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	if err := analysis.NewCache(dir).Clean(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	return exitOK
+}
+
+// runDiff implements 'diff' command, comparing dependencies
+// of the working tree with the given git revision.
+func runDiff(ctx context.Context, cfg *analysis.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: depscheck [options] diff <base-ref> [packages]")
+		return exitError
+	}
+	rev, patterns := args[0], args[1:]
+
+	head, _, err := analyze(ctx, cfg, "", patterns)
+	if err != nil {
+		fmt.Println(err)
+		return exitError
+	}
+
+	wt, dir, err := NewWorktree(".", rev)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+	defer wt.Remove()
+
+	base, _, err := analyze(ctx, cfg, dir, patterns)
+	if err != nil {
+		fmt.Printf("%s: %v\n", rev, err)
+		return exitError
+	}
+
+	d := analysis.DiffResults(base.Aggregate, head.Aggregate)
+	d.Base, d.Head = rev, "working tree"
+	if *format == "json" {
+		if err := d.WriteJSON(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		return exitOK
+	}
+	d.WriteMarkdown(os.Stdout)
+	return exitOK
+}
+
+// runExtract implements 'extract' command, copying code of
+// the dependency into a package of the project.
+func runExtract(ctx context.Context, cfg *analysis.Config, args []string) int {
+	fs := flag.NewFlagSet("extract", flag.ContinueOnError)
+	dir := fs.String("dir", "", "Directory of the new package, relative to the module root (default: internal/<name>)")
+	dryRun := fs.Bool("n", false, "Print files to be written, without writing them")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: depscheck [options] extract [-dir dir] [-n] <import-path> [packages]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitError
+	}
+	path, patterns := fs.Arg(0), fs.Args()[1:]
+
+	e, err := analysis.Extract(ctx, options(cfg, ""), path, *dir, patterns...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if !*dryRun {
+		if err := e.Write(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+	printExtraction(e, *dryRun)
+	return exitOK
+}
+
+// runInterfaces implements 'interfaces' command, generating
+// interfaces for dependency types used through a few methods.
+func runInterfaces(ctx context.Context, cfg *analysis.Config, args []string) int {
+	fs := flag.NewFlagSet("interfaces", flag.ContinueOnError)
+	methods := fs.Int("methods", 3, "Max number of methods of the type, used by the package")
+	rewrite := fs.Bool("rewrite", false, "Change params of unexported funcs and unexported fields of these types to interfaces")
+	dryRun := fs.Bool("n", false, "Print interfaces and files to be written, without writing them")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: depscheck [options] interfaces [-methods N] [-rewrite] [-n] [packages]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+
+	d, err := analysis.Decouple(ctx, options(cfg, ""), *methods, *rewrite, fs.Args()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitError
+	}
+
+	if !*dryRun {
+		if err := d.Write(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+	printDecoupling(d, *dryRun)
+	return exitOK
+}
+
+// readConfig loads project config file, if any, and uses it
+// as defaults for the flags, not set in command line.
+func readConfig() (*analysis.Config, error) {
+	filename := *configFile
+	if filename == "" {
+		var err error
+		if filename, err = analysis.FindConfig("."); err != nil || filename == "" {
+			return analysis.NewConfig(), err
+		}
+	}
+
+	cfg, err := analysis.LoadConfig(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	for name, value := range cfg.Flags() {
+		if set[name] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return nil, fmt.Errorf("%s: invalid %s: %v", filename, name, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// readPolicy builds policy from the config, layering policy file,
+// if any, and command line flags on top of it.
+func readPolicy(cfg *analysis.Config) (analysis.Policy, error) {
+	policy := analysis.Policy{Thresholds: cfg.Thresholds}
+	if err := policy.Rules.Set(strings.Join(cfg.FailOn, ",")); err != nil {
+		return policy, err
+	}
+
+	if *policyFile != "" {
+		var err error
+		if policy, err = analysis.LoadPolicy(*policyFile, policy); err != nil {
+			return policy, err
+		}
+	}
+
+	if *failOn != "" {
+		policy.Rules = nil
+		if err := policy.Rules.Set(*failOn); err != nil {
+			return policy, fmt.Errorf("-fail-on: %v", err)
+		}
+	}
+	if err := policy.Thresholds.Set(*thresholds); err != nil {
+		return policy, fmt.Errorf("-thresholds: %v", err)
+	}
+
+	return policy, nil
 }
 
 // Usage prints usage information for this program.
 func Usage() {
-	fmt.Fprintf(os.Stderr, "Usage: %s [options] <args>\n\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Usage: %s [options] <packages>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s [options] diff <base-ref> <packages>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s [options] extract [-dir dir] [-n] <import-path> <packages>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s [options] interfaces [-methods N] [-rewrite] [-n] <packages>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s cache clean\n\n", os.Args[0])
 	flag.PrintDefaults()
-	fmt.Fprintf(os.Stderr, "\n%s\n", loader.FromArgsUsage)
+	fmt.Fprintf(os.Stderr, "\n%s\n", patternsUsage)
+	fmt.Fprintf(os.Stderr, "\n%s\n", diffUsage)
+	fmt.Fprintf(os.Stderr, "\n%s\n", extractUsage)
+	fmt.Fprintf(os.Stderr, "\n%s\n", interfacesUsage)
+	fmt.Fprintf(os.Stderr, "\n%s\n", cacheUsage)
+	fmt.Fprintf(os.Stderr, "\n%s\n", exitCodesUsage)
 }
+
+const patternsUsage = `<packages> is a list of package patterns, as understood by 'go list':
+import paths, relative paths ("." by default), "./..." or a list of .go files
+from a single directory. Packages are resolved with the go command, so Go modules,
+go.work workspaces and replace directives are all respected. Dependencies are read
+from the module cache and no network access is needed once they're downloaded.`
+
+const diffUsage = `The diff command analyzes packages in the working tree and at the given local
+git revision (checked out into a temporary worktree) and prints added/removed
+packages and selectors and changes in LOCCum, Depth and Calls as markdown.`
+
+const extractUsage = `The extract command copies code of the dependency, used by the packages, into
+a new package of the module (internal/<name> by default, see -dir), keeping its
+license, and rewrites imports of the packages to it. Nothing is written if the
+result doesn't compile; -n prints files to be written instead.`
+
+const interfacesUsage = `The interfaces command finds dependency types, of which packages call only
+a few methods (see -methods) and never access fields, and generates interfaces
+with exactly these methods in interfaces.go of every package. With -rewrite,
+params of unexported funcs and unexported fields of these types are changed
+to interfaces too.`
+
+const cacheUsage = `Dependencies from the module cache never change, so LOC and calls of their
+functions are cached in the user cache directory and reused by later runs.
+Use -no-cache to disable it, and the 'cache clean' command to remove cached data.`
+
+const exitCodesUsage = `Exit codes: 0 - ok, 1 - some of -fail-on policy rules fired, 2 - error.`
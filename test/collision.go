@@ -0,0 +1,17 @@
+package main
+
+import (
+	errorsA "github.com/divan/depscheck/test/errs/a"
+	errorsB "github.com/divan/depscheck/test/errs/b"
+	"github.com/divan/depscheck/test/lib"
+	libv2 "github.com/divan/depscheck/test/lib/v2"
+)
+
+func main() {
+	_ = errorsA.New("a")
+	_ = errorsB.New("b")
+	_ = errorsB.New("c")
+
+	_ = lib.Parse("foo")
+	_, _ = libv2.Parse("bar")
+}
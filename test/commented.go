@@ -0,0 +1,7 @@
+package main
+
+import "github.com/divan/depscheck/test/commented"
+
+func main() {
+	_ = commented.Compact()
+}
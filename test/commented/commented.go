@@ -0,0 +1,20 @@
+package commented
+
+// Documented is a small function, which looks big
+// because of comments and blank lines.
+func Documented(a, b int) int {
+	// This function adds two numbers.
+	//
+	// It's really simple, but the comment
+	// makes it look big.
+
+	sum := a + b
+
+	/*
+		block comments are not code too
+	*/
+	return sum
+}
+
+// Compact calls Documented.
+func Compact() int { return Documented(1, 2) }
@@ -0,0 +1,8 @@
+package main
+
+import "github.com/divan/depscheck/test/complexity"
+
+func main() {
+	_ = complexity.Classify(nil, true)
+	_ = complexity.Fact(5)
+}
@@ -0,0 +1,24 @@
+package complexity
+
+// Classify is short, but hard to follow.
+func Classify(words []string, strict bool) int {
+	var n int
+	for _, w := range words {
+		if w == "" || strict {
+			if len(w) > 3 && w[0] == 'x' {
+				n++
+			} else {
+				continue
+			}
+		}
+	}
+	return n
+}
+
+// Fact is short and simple, despite recursion.
+func Fact(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return n * Fact(n-1)
+}
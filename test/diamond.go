@@ -0,0 +1,7 @@
+package main
+
+import "github.com/divan/depscheck/test/diamond"
+
+func main() {
+	_ = diamond.Left() + diamond.Right()
+}
@@ -0,0 +1,16 @@
+package diamond
+
+func Left() int {
+	return helper() + 1
+}
+
+func Right() int {
+	return helper() + 2
+}
+
+func helper() int {
+	x := 1
+	x++
+	x++
+	return x
+}
@@ -0,0 +1,10 @@
+package errors
+
+import "fmt"
+
+func New(text string) error {
+	if text == "" {
+		text = "unknown error"
+	}
+	return fmt.Errorf("b: %s", text)
+}
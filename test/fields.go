@@ -0,0 +1,16 @@
+package main
+
+import "github.com/divan/depscheck/test/fields"
+
+func main() {
+	opts := fields.Options{Timeout: 10}
+	opts.Retries = 3
+	opts.Limits.Max = 5
+
+	_ = fields.Point{1, 2}
+
+	resp := fields.Get()
+	if resp.Status == 200 {
+		_ = resp.Body + resp.Body
+	}
+}
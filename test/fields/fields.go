@@ -0,0 +1,28 @@
+package fields
+
+// Options configure a client.
+type Options struct {
+	Timeout int
+	Retries int
+	Limits  struct {
+		Max int
+	}
+}
+
+type Point struct {
+	X, Y int
+}
+
+type Response struct {
+	Status int
+	Body   string
+}
+
+func Get() *Response {
+	return &Response{Status: 200}
+}
+
+// Status reads field of Response, which has the same name.
+func Status() int {
+	return Get().Status
+}
@@ -0,0 +1,7 @@
+package main
+
+import "github.com/divan/depscheck/test/fields"
+
+func main() {
+	_ = fields.Status()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/divan/depscheck/test/funcref"
+)
+
+func main() {
+	_ = funcref.Doubled([]int{1, 2})
+	_ = funcref.Greeter(funcref.Greeting{})()
+	_ = funcref.Farewell(funcref.Greeting{})
+
+	// func passed as an argument
+	xs := []int{3, 1, 2}
+	sort.Slice(xs, func(i, j int) bool {
+		return funcref.Negate(xs[i]) < funcref.Negate(xs[j])
+	})
+	apply(xs, funcref.Negate)
+}
+
+func apply(xs []int, fn func(int) int) {
+	for i := range xs {
+		xs[i] = fn(xs[i])
+	}
+}
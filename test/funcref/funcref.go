@@ -0,0 +1,45 @@
+package funcref
+
+type Greeting struct {
+	Name string
+}
+
+func (g Greeting) Hello() string {
+	return "Hello, " + g.Name
+}
+
+func (g Greeting) Bye() string {
+	return "Bye, " + g.Name
+}
+
+// Doubled passes double as an argument.
+func Doubled(xs []int) []int {
+	return apply(xs, double)
+}
+
+func apply(xs []int, fn func(int) int) []int {
+	ret := make([]int, len(xs))
+	for i, x := range xs {
+		ret[i] = fn(x)
+	}
+	return ret
+}
+
+func double(x int) int {
+	return x * 2
+}
+
+// Greeter returns method value.
+func Greeter(g Greeting) func() string {
+	return g.Hello
+}
+
+// Farewell uses method expression.
+func Farewell(g Greeting) string {
+	bye := Greeting.Bye
+	return bye(g)
+}
+
+func Negate(x int) int {
+	return -x
+}
@@ -0,0 +1,11 @@
+package main
+
+import "github.com/divan/depscheck/test/shape"
+
+func main() {
+	var s shape.Shape = shape.NewSquare(2)
+	_ = s.Area()
+
+	describe := shape.Describe
+	_ = describe(s)
+}
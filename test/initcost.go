@@ -0,0 +1,12 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/divan/depscheck/test/initcost"
+	_ "github.com/divan/depscheck/test/initcost/driver"
+)
+
+func main() {
+	fmt.Println(initcost.Version)
+}
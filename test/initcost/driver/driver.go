@@ -0,0 +1,15 @@
+// Package driver registers itself, so it's imported
+// for initialization only.
+package driver
+
+import "github.com/divan/depscheck/test/initcost"
+
+type conn struct{}
+
+func (c *conn) Open() error {
+	return nil
+}
+
+func init() {
+	initcost.Register("test", &conn{})
+}
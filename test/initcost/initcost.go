@@ -0,0 +1,26 @@
+package initcost
+
+var registry = map[string]Driver{}
+
+// Default is computed on import.
+var Default = compute(10)
+
+// Version is a constant, but importing the package to use
+// it runs initialization all the same.
+const Version = "1.0"
+
+type Driver interface {
+	Open() error
+}
+
+func Register(name string, d Driver) {
+	registry[name] = d
+}
+
+func compute(n int) int {
+	sum := 0
+	for i := 0; i < n; i++ {
+		sum += i
+	}
+	return sum
+}
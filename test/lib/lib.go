@@ -0,0 +1,5 @@
+package lib
+
+func Parse(s string) int {
+	return len(s)
+}
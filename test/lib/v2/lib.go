@@ -0,0 +1,9 @@
+package lib
+
+func Parse(s string) (int, error) {
+	n := len(s)
+	if n == 0 {
+		return 0, nil
+	}
+	return n, nil
+}
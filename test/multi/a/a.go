@@ -0,0 +1,11 @@
+package a
+
+import (
+	"github.com/divan/depscheck/test/foo"
+	sample "github.com/divan/depscheck/test/sample"
+)
+
+func A() int {
+	foo.Foo(1)
+	return sample.SampleFunc()
+}
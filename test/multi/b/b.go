@@ -0,0 +1,7 @@
+package b
+
+import sample "github.com/divan/depscheck/test/sample"
+
+func B() int {
+	return sample.SampleFunc()
+}
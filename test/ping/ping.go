@@ -0,0 +1,11 @@
+package ping
+
+import "github.com/divan/depscheck/test/pong"
+
+type Ping struct{}
+
+// Pong calls back pong.Serve, which calls Pong through the
+// interface, so they form a cross-package cycle.
+func (p Ping) Pong(n int) int {
+	return pong.Serve(p, n)
+}
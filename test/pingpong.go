@@ -0,0 +1,8 @@
+package main
+
+import "github.com/divan/depscheck/test/ping"
+
+func main() {
+	var p ping.Ping
+	_ = p.Pong(3)
+}
@@ -0,0 +1,12 @@
+package pong
+
+type Ponger interface {
+	Pong(n int) int
+}
+
+func Serve(p Ponger, n int) int {
+	if n == 0 {
+		return 0
+	}
+	return p.Pong(n - 1)
+}
@@ -0,0 +1,31 @@
+package shape
+
+import "strconv"
+
+type Shape interface {
+	Area() int
+}
+
+type Square struct {
+	side int
+}
+
+func NewSquare(side int) Square {
+	return Square{side: side}
+}
+
+func (s Square) Area() int {
+	a := s.side
+	a *= s.side
+	return a
+}
+
+func Describe(s Shape) string {
+	fn := areaString
+	return fn(s)
+}
+
+func areaString(s Shape) string {
+	a := s.Area()
+	return strconv.Itoa(a)
+}
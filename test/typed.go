@@ -0,0 +1,10 @@
+package main
+
+import "github.com/divan/depscheck/test/typed"
+
+func main() {
+	_ = typed.Clamp(typed.Default, 20)
+
+	var l typed.List
+	_ = l
+}
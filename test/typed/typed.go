@@ -0,0 +1,26 @@
+package typed
+
+const Limit = 10
+
+type Level int
+
+type Config struct {
+	Max   int
+	Level Level
+}
+
+var Default = Config{Max: Limit}
+
+// List refers to itself, which is not a cycle.
+type List struct {
+	Next *List
+	Config
+}
+
+// Clamp calls nothing, but can't be copied without Config.
+func Clamp(c Config, x int) int {
+	if x > c.Max {
+		return c.Max
+	}
+	return x
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/divan/depscheck/analysis"
+	"github.com/olekukonko/tablewriter"
+)
+
+// printStats prints results to stdout in a pretty table form.
+func printStats(r *analysis.Result) {
+	if len(r.Counter) == 0 {
+		return
+	}
+	selectors := r.All()
+	sort.Sort(analysis.ByID(selectors))
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Pkg", "Recv", "Name", "Type", "Count", "LOC", "LOCCum", "LOCUniq", "SLOC", "Stmts", "Cyclo", "Cognit", "Depth", "DepthInt"})
+
+	var results [][]string
+	var lastPkg string
+	for _, sel := range selectors {
+		pkg := ""
+		if lastPkg != sel.Pkg.ID() {
+			lastPkg = sel.Pkg.ID()
+			pkg = sel.Pkg.Name
+		}
+		loc := fmt.Sprintf("%d", sel.LOC)
+		locCum := fmt.Sprintf("%d", sel.LOCCum())
+		locUniq := fmt.Sprintf("%d", sel.LOCUnique())
+		sloc := fmt.Sprintf("%d", sel.SLOC)
+		var stmts, cyclo, cognit, depth, depthInt string
+		if sel.Type == "func" || sel.Type == "method" {
+			stmts = fmt.Sprintf("%d", sel.Stmts)
+			cyclo = fmt.Sprintf("%d", sel.Cyclomatic)
+			cognit = fmt.Sprintf("%d", sel.Cognitive)
+			depth = fmt.Sprintf("%d", sel.Depth())
+			depthInt = fmt.Sprintf("%d", sel.DepthInternal())
+		}
+		count := fmt.Sprintf("%d", r.Counter[sel.ID()])
+		results = append(results, []string{pkg, sel.Recv, sel.Name, sel.Type, count, loc, locCum, locUniq, sloc, stmts, cyclo, cognit, depth, depthInt})
+	}
+	for _, v := range results {
+		table.Append(v)
+	}
+	table.Render() // Send output
+}
+
+// printPackagesStats prints package stats to stdout in a pretty table form.
+func printPackagesStats(r *analysis.Result) {
+	stats := r.PackagesStats()
+	if len(stats) == 0 {
+		return
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Pkg", "Path", "Module", "Count", "Calls", "LOCCum", "LOCUniq", "SLOCCum", "StmtsCum", "Cyclo", "CycloMax", "Cognit", "CognitMax", "Depth", "DepthInt", "InitLOC"})
+
+	var results [][]string
+	for _, stat := range stats {
+		count := fmt.Sprintf("%d", stat.DepsCount)
+		callsCount := fmt.Sprintf("%d", stat.DepsCallsCount)
+		loc := fmt.Sprintf("%d", stat.LOCCum)
+		locUniq := fmt.Sprintf("%d", stat.LOCUnique)
+		sloc := fmt.Sprintf("%d", stat.SLOCCum)
+		stmts := fmt.Sprintf("%d", stat.StmtsCum)
+		cyclo := fmt.Sprintf("%d", stat.Cyclomatic)
+		cycloMax := fmt.Sprintf("%d", stat.CyclomaticMax)
+		cognit := fmt.Sprintf("%d", stat.Cognitive)
+		cognitMax := fmt.Sprintf("%d", stat.CognitiveMax)
+		depth := fmt.Sprintf("%d", stat.Depth)
+		depthInt := fmt.Sprintf("%d", stat.DepthInternal)
+		initLOC := fmt.Sprintf("%d", stat.InitLOCCum)
+		results = append(results, []string{stat.Name, stat.Path, stat.ModuleString(), count, callsCount, loc, locUniq, sloc, stmts, cyclo, cycloMax, cognit, cognitMax, depth, depthInt, initLOC})
+	}
+	for _, v := range results {
+		table.Append(v)
+	}
+	table.Render() // Send output
+}
+
+// printTotals prints totals for each of our packages.
+func printTotals(rs *analysis.Results) {
+	for _, path := range rs.Packages {
+		fmt.Printf(" - %s\n", rs.Results[path].Totals(path))
+	}
+}
+
+// printUsedBy prints table of dependencies with our packages,
+// using them.
+func printUsedBy(rs *analysis.Results) {
+	stats := rs.Aggregate.PackagesStats()
+	if len(stats) == 0 {
+		return
+	}
+	usedBy := rs.UsedBy()
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Pkg", "Path", "Module", "Used by"})
+
+	var results [][]string
+	for _, stat := range stats {
+		results = append(results, []string{stat.Name, stat.Path, stat.ModuleString(), strings.Join(usedBy[stat.ID()], "\n")})
+	}
+	for _, v := range results {
+		table.Append(v)
+	}
+	table.Render() // Send output
+}
+
+// printBlankImports prints packages, imported for their
+// initialization only, if any.
+func printBlankImports(r *analysis.Result) {
+	var blank []*analysis.PackageStat
+	for _, stat := range r.PackagesStats() {
+		if stat.Blank {
+			blank = append(blank, stat)
+		}
+	}
+	if len(blank) == 0 {
+		return
+	}
+
+	fmt.Printf("Blank imports (%d):\n", len(blank))
+	for _, stat := range blank {
+		fmt.Printf(" - %s (%s): %d LOC of initialization\n", stat.Name, stat.Path, stat.InitLOCCum)
+	}
+}
+
+// printCycles prints cycles of mutually recursive functions, if any.
+func printCycles(r *analysis.Result) {
+	cycles := r.Cycles()
+	if len(cycles) == 0 {
+		return
+	}
+
+	fmt.Printf("Cycles (%d):\n", len(cycles))
+	for _, cycle := range cycles {
+		var names []string
+		for _, sel := range cycle {
+			name := sel.Pkg.Name + "." + sel.Name
+			if sel.Recv != "" {
+				name = fmt.Sprintf("%s.(%s).%s", sel.Pkg.Name, sel.Recv, sel.Name)
+			}
+			names = append(names, name)
+		}
+		fmt.Printf(" - %s\n", strings.Join(names, ", "))
+	}
+}
+
+// printSuggestions analyzes results and print suggestions on deps.
+//
+// It attempts to suggest which dependencies could be
+// copied to your source because of its small size.
+func printSuggestions(r *analysis.Result) {
+	if len(r.Counter) == 0 {
+		return
+	}
+
+	candidates := r.Candidates()
+	for _, p := range candidates {
+		path := p.Path
+		if p.Module != "" {
+			path = fmt.Sprintf("%s, module %s", p.Path, p.ModuleString())
+		}
+		fmt.Printf(" - Package %s (%s) is a good candidate for removing from dependencies.\n", p.Name, path)
+		metric := r.Thresholds.Metric
+		fmt.Printf("   Only %d %s used, through %d selectors, with %d level of nesting\n", p.Size(metric), analysis.MetricName(metric), p.DepsCount, p.DepthInternal)
+	}
+
+	if len(candidates) == 0 {
+		fmt.Println("Cool, looks like your dependencies are sane.")
+	}
+}
+
+// printViolations prints policy violations, if any.
+func printViolations(violations []analysis.Violation) {
+	if len(violations) == 0 {
+		return
+	}
+
+	fmt.Printf("Policy violations (%d):\n", len(violations))
+	for _, v := range violations {
+		fmt.Printf(" - %s\n", v)
+	}
+}
+
+// printRegressions prints regressions compared to baseline.
+func printRegressions(reg *analysis.Regressions) {
+	if reg.Empty() {
+		fmt.Println("No new dependency regressions compared to baseline.")
+		return
+	}
+
+	fmt.Printf("Compared to baseline: %d new packages, %d new selectors, %d grown packages.\n",
+		len(reg.NewPackages), len(reg.NewSelectors), len(reg.Grown))
+	for _, stat := range reg.NewPackages {
+		fmt.Printf(" + package %s (%s): %d LOC, %d calls\n", stat.Name, stat.Path, stat.LOCCum, stat.DepsCallsCount)
+	}
+	for _, sel := range reg.NewSelectors {
+		fmt.Printf(" + selector %s\n", sel.ID())
+	}
+	for _, g := range reg.Grown {
+		p := g.Package
+		fmt.Printf(" ^ package %s (%s): LOC %d -> %d, Depth %d -> %d, DepthInt %d -> %d\n",
+			p.Name, p.Path, g.Old.LOCCum, p.LOCCum, g.Old.Depth, p.Depth, g.Old.DepthInternal, p.DepthInternal)
+	}
+}
+
+// printDecoupling prints generated interfaces and files,
+// written or to be written, if dryRun is set.
+func printDecoupling(d *analysis.Decoupling, dryRun bool) {
+	if len(d.Interfaces) == 0 {
+		fmt.Println("No dependency types are used through a few methods only.")
+		return
+	}
+
+	written := "Written"
+	if dryRun {
+		written = "Would write"
+	}
+
+	var pkg string
+	for _, iface := range d.Interfaces {
+		if iface.Pkg != pkg {
+			pkg = iface.Pkg
+			fmt.Printf("%s:\n", pkg)
+		}
+		fmt.Printf(" - %s for %s (%s): %s\n", iface.Name, iface.Type, iface.Path, strings.Join(iface.Methods, ", "))
+		if len(iface.Rewritten) > 0 {
+			fmt.Printf("   used by %s\n", strings.Join(iface.Rewritten, ", "))
+		}
+		if dryRun {
+			fmt.Printf("\n%s\n\n", iface.Decl)
+		}
+	}
+
+	fmt.Printf("%s files:\n", written)
+	wd, _ := os.Getwd()
+	for _, filename := range d.Filenames() {
+		if rel, err := filepath.Rel(wd, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			filename = rel
+		}
+		fmt.Printf(" - %s\n", filename)
+	}
+}
+
+// printExtraction prints what is copied by extract command, and
+// files written, or to be written for dry run.
+func printExtraction(e *analysis.Extraction, dryRun bool) {
+	copied, written := "Copied", "Written"
+	if dryRun {
+		copied, written = "Would copy", "Would write"
+	}
+
+	fmt.Printf("%s %d declarations of %s into %s:\n", copied, len(e.Objects), e.Path, e.NewPath)
+	for _, name := range e.Objects {
+		fmt.Printf(" - %s\n", name)
+	}
+
+	fmt.Printf("%s files:\n", written)
+	wd, _ := os.Getwd()
+	for _, filename := range e.Filenames() {
+		if rel, err := filepath.Rel(wd, filename); err == nil && !strings.HasPrefix(rel, "..") {
+			filename = rel
+		}
+		fmt.Printf(" - %s\n", filename)
+	}
+	if !dryRun {
+		fmt.Printf("Run 'go mod tidy' to drop %s from requirements, if it's not used anymore.\n", e.Path)
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Worktree is a temporary git worktree, checked out
+// at the given revision.
+type Worktree struct {
+	Dir  string // root of the worktree
+	repo string
+}
+
+// NewWorktree checks out revision of git repository containing dir
+// into a temporary worktree. It works with local revisions only
+// and never touches network.
+//
+// It returns path inside worktree, corresponding to dir.
+func NewWorktree(dir, rev string) (*Worktree, string, error) {
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, "", err
+	}
+	prefix, err := git(dir, "rev-parse", "--show-prefix")
+	if err != nil {
+		return nil, "", err
+	}
+
+	tmp, err := os.MkdirTemp("", "depscheck-diff-")
+	if err != nil {
+		return nil, "", err
+	}
+
+	if _, err := git(root, "worktree", "add", "--detach", tmp, rev); err != nil {
+		os.RemoveAll(tmp)
+		return nil, "", err
+	}
+
+	wt := &Worktree{Dir: tmp, repo: root}
+	return wt, filepath.Join(tmp, filepath.FromSlash(prefix)), nil
+}
+
+// Remove removes worktree.
+func (wt *Worktree) Remove() error {
+	_, err := git(wt.repo, "worktree", "remove", "--force", wt.Dir)
+	os.RemoveAll(wt.Dir)
+	return err
+}
+
+// git runs git command in dir, returning trimmed output.
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.TrimSpace(string(out)), nil
+}